@@ -0,0 +1,124 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// FromCloser returns a HookFunc that closes c. The context is ignored.
+func FromCloser(c io.Closer) HookFunc {
+	return func(context.Context) error {
+		return c.Close()
+	}
+}
+
+// FromFunc returns a HookFunc that calls fn and always succeeds. The context
+// is ignored.
+func FromFunc(fn func()) HookFunc {
+	return func(context.Context) error {
+		fn()
+		return nil
+	}
+}
+
+// FromErrFunc returns a HookFunc that calls fn and returns its error. The
+// context is ignored.
+func FromErrFunc(fn func() error) HookFunc {
+	return func(context.Context) error {
+		return fn()
+	}
+}
+
+// FromWaitGroup returns a HookFunc that waits for wg. Unlike wg.Wait, it
+// returns the context's error once the context is done, leaving a goroutine
+// behind that waits for wg to be done.
+func FromWaitGroup(wg *sync.WaitGroup) HookFunc {
+	return func(ctx context.Context) error {
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// CloseChan returns a HookFunc that closes ch, for example to tell workers
+// ranging over it to stop. The context is ignored. Closing is done at most
+// once, so that running the registry again does not panic.
+func CloseChan[T any](ch chan T) HookFunc {
+	var once sync.Once
+	return func(context.Context) error {
+		once.Do(func() {
+			close(ch)
+		})
+		return nil
+	}
+}
+
+// FromCancel returns a HookFunc that calls cancel and then waits until done
+// is closed, or returns the context's error once the context is done. It
+// suits workers that are stopped by canceling their context and close done
+// when they have returned: canceling them without waiting would let
+// shutdown continue while they still use resources.
+func FromCancel(cancel context.CancelFunc, done <-chan struct{}) HookFunc {
+	return func(ctx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// ErrNotResource is returned by AddResource for values without a supported
+// cleanup method.
+var ErrNotResource = errors.New("value has no Shutdown, Close, Flush or Stop method")
+
+// Resource returns a HookFunc that releases v by calling the first of the
+// following methods it has:
+//
+//	Shutdown(context.Context) error
+//	Close() error
+//	Flush() error
+//	Stop()
+//
+// The context is only passed to Shutdown. If v has none of these methods,
+// Resource returns an error wrapping ErrNotResource.
+func Resource(v any) (HookFunc, error) {
+	switch v := v.(type) {
+	case interface{ Shutdown(context.Context) error }:
+		return v.Shutdown, nil
+	case io.Closer:
+		return FromCloser(v), nil
+	case interface{ Flush() error }:
+		return FromErrFunc(v.Flush), nil
+	case interface{ Stop() }:
+		return FromFunc(v.Stop), nil
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrNotResource, v)
+	}
+}
+
+// AddResource registers a hook that releases v as described by Resource,
+// configured with the given options. It cuts the boilerplate of wiring many
+// clients, whose cleanup methods differ. If v has no supported method,
+// nothing is registered and an error wrapping ErrNotResource is returned.
+func (r *Registry) AddResource(v any, opts ...HookOption) (Token, error) {
+	fn, err := Resource(v)
+	if err != nil {
+		return Token{}, err
+	}
+	return r.Register(fn, opts...), nil
+}
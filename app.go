@@ -0,0 +1,257 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DefaultShutdownTimeout is the time an App allows for shutdown by default.
+// It matches the default termination grace period of Kubernetes.
+const DefaultShutdownTimeout = 30 * time.Second
+
+// AppOption configures an App.
+type AppOption func(*App)
+
+// WithShutdownTimeout sets the time an App allows for shutdown. If d is not
+// positive, shutdown is not limited. The default is DefaultShutdownTimeout.
+func WithShutdownTimeout(d time.Duration) AppOption {
+	return func(a *App) {
+		a.timeout = d
+	}
+}
+
+// WithExitCode sets the function that maps the error of starting, running
+// or shutting down an App to the status code returned by Run. The default is
+// ExitCode.
+func WithExitCode(fn ExitCodeFunc) AppOption {
+	return func(a *App) {
+		a.exitCode = fn
+	}
+}
+
+// WithSignals sets the signals an App shuts down on. The default is SIGINT
+// and SIGTERM, which is also used if no signals are given.
+func WithSignals(signals ...os.Signal) AppOption {
+	return func(a *App) {
+		if len(signals) > 0 {
+			a.signals = signals
+		}
+	}
+}
+
+// WithReloadSignals sets the signals an App runs its reload hooks on. The
+// default is SIGHUP. Without signals, reload hooks are only run by calling
+// Reload.
+func WithReloadSignals(signals ...os.Signal) AppOption {
+	return func(a *App) {
+		a.reloadSignals = signals
+	}
+}
+
+// App runs a complete process: it starts components with start hooks, runs
+// background workers, blocks until it is asked to terminate or a worker
+// fails, and then shuts everything down gracefully.
+//
+//	app := hook.NewApp()
+//	app.Append(hook.LifecycleHook{Name: "db", OnStart: db.Open, OnStop: db.Close})
+//	app.Go("server", srv.Serve)
+//	os.Exit(app.Run(context.Background()))
+type App struct {
+	lifecycle *Lifecycle
+	stop      *Registry
+	reload    *Registry
+	signals   []os.Signal
+	timeout   time.Duration
+	exitCode  ExitCodeFunc
+	failed    chan error
+
+	reloadSignals []os.Signal
+
+	mu      sync.Mutex
+	started bool
+	workers []appWorker
+	ready   latch
+}
+
+// appWorker is a worker registered with App.Go before the app was started.
+type appWorker struct {
+	name string
+	fn   HookFunc
+	opts []GoOption
+}
+
+// NewApp creates a new App.
+func NewApp(opts ...AppOption) *App {
+	a := &App{
+		lifecycle: NewLifecycle(),
+		stop:      New(),
+		reload:    New(),
+		signals:   []os.Signal{os.Interrupt, syscall.SIGTERM},
+		timeout:   DefaultShutdownTimeout,
+		exitCode:  ExitCode,
+		failed:    make(chan error, 1),
+
+		reloadSignals: []os.Signal{syscall.SIGHUP},
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Registry returns the registry run on shutdown. Stop hooks can be
+// registered with it directly, for example by adapters such as hookhttp.
+func (a *App) Registry() *Registry {
+	return a.stop
+}
+
+// Append registers pairs of start and stop hooks. The start hooks run one
+// after another when the app starts; the stop hooks run in reverse order
+// after the registry on shutdown. See Lifecycle.
+func (a *App) Append(hooks ...LifecycleHook) {
+	a.lifecycle.Append(hooks...)
+}
+
+// OnStart registers a hook run when the app starts. See Append.
+func (a *App) OnStart(name string, fn HookFunc) {
+	a.lifecycle.Append(LifecycleHook{Name: name, OnStart: fn})
+}
+
+// OnStop registers a hook with the registry run on shutdown.
+func (a *App) OnStop(name string, fn HookFunc, opts ...HookOption) Token {
+	return a.stop.AddNamed(name, fn, opts...)
+}
+
+// Go registers a background worker, which is started once the start hooks
+// have completed, or right away if the app is already running. The worker is
+// supervised like with Registry.Go. If it fails for good before shutdown,
+// the app shuts down.
+func (a *App) Go(name string, fn HookFunc, opts ...GoOption) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.started {
+		a.start(appWorker{name: name, fn: fn, opts: opts})
+		return
+	}
+	a.workers = append(a.workers, appWorker{name: name, fn: fn, opts: opts})
+}
+
+// start starts a worker with the registry.
+func (a *App) start(w appWorker) {
+	opts := append(slices.Clip(w.opts), func(wk *worker) {
+		wk.onExit = func(err error) {
+			select {
+			case a.failed <- fmt.Errorf("worker %q: %w", w.name, err):
+			default:
+			}
+		}
+	})
+	a.stop.Go(w.name, w.fn, opts...)
+}
+
+// OnReload registers a hook with the registry run by Reload, such as
+// reloading the configuration. Reload hooks are distinct from stop hooks.
+func (a *App) OnReload(name string, fn HookFunc, opts ...HookOption) Token {
+	return a.reload.AddNamed(name, fn, opts...)
+}
+
+// Reload runs the reload hooks and returns their errors joined, like
+// Registry.Run. Run calls it when one of the reload signals is received. A
+// call made while a reload is in progress waits for it and returns its
+// result instead of reloading again.
+func (a *App) Reload(ctx context.Context) error {
+	return a.reload.Run(ctx, WithCoalesce())
+}
+
+// Ready returns a channel that is closed once the start hooks have completed
+// successfully and the workers have been started.
+func (a *App) Ready() <-chan struct{} {
+	return a.ready.done()
+}
+
+// OnReady registers fn to be called once the app is ready, or right away if
+// it already is. See Ready.
+func (a *App) OnReady(fn func()) {
+	a.ready.notify(fn)
+}
+
+// Run starts the app and blocks until one of its signals is received, a
+// worker fails or ctx is done. Meanwhile, the reload hooks are run whenever
+// a reload signal is received. It then runs the registry and the stop hooks
+// with a context that is detached from ctx and limited to the shutdown
+// timeout. A second signal received during shutdown terminates the process,
+// as with Listener.
+//
+// Run returns the status code the process should exit with, as determined
+// by the function set with WithExitCode from the errors of the start hooks,
+// a failed worker and shutdown. By default, it is 0 after a clean shutdown.
+// Errors are written to standard error.
+func (a *App) Run(ctx context.Context) int {
+	if err := a.lifecycle.Start(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "hook: %v\n", err)
+		return a.exitCode(err)
+	}
+
+	a.mu.Lock()
+	a.started = true
+	for _, w := range a.workers {
+		a.start(w)
+	}
+	a.workers = nil
+	a.mu.Unlock()
+	a.ready.set()
+
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, a.signals...)
+	defer signal.Stop(sigs)
+
+	var reloads chan os.Signal
+	if len(a.reloadSignals) > 0 {
+		reloads = make(chan os.Signal, 1)
+		signal.Notify(reloads, a.reloadSignals...)
+		defer signal.Stop(reloads)
+	}
+
+	var failure error
+wait:
+	for {
+		select {
+		case <-sigs:
+			break wait
+		case failure = <-a.failed:
+			fmt.Fprintf(os.Stderr, "hook: %v\n", failure)
+			break wait
+		case <-ctx.Done():
+			break wait
+		case <-reloads:
+			go func() {
+				if err := a.Reload(ctx); err != nil {
+					fmt.Fprintf(os.Stderr, "hook: reload: %v\n", err)
+				}
+			}()
+		}
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go new(Listener).forceExit(sigs, done)
+
+	ctx = context.WithoutCancel(ctx)
+	if a.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = withTimeout(ctx, a.timeout, "shutdown timeout")
+		defer cancel()
+	}
+	err := errors.Join(a.stop.Run(ctx), a.lifecycle.Stop(ctx))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "hook: %v\n", err)
+	}
+	return a.exitCode(errors.Join(failure, err))
+}
@@ -0,0 +1,59 @@
+package hook
+
+import "context"
+
+// flight is a run in progress that coalescing runs can wait for.
+type flight struct {
+	done   chan struct{}
+	report *Report
+	err    error
+}
+
+// WithCoalesce makes a run join a coalescing run of the same Registry that is
+// already in progress, instead of executing every hook a second time. The
+// joining call waits for the run in progress and returns its report and
+// error, unless its own context is done first. This is useful when Run may
+// be triggered from several places at once, such as a signal handler and a
+// failing health check. Runs without this option are never coalesced.
+func WithCoalesce() Option {
+	return func(c *config) {
+		c.coalesce = true
+	}
+}
+
+// coalesced runs the registry like execute, joining a coalescing run that is
+// already in progress if there is one.
+func (r *Registry) coalesced(ctx context.Context, cfg config) (*Report, error) {
+	r.runMu.Lock()
+	if f := r.flight; f != nil {
+		r.runMu.Unlock()
+		select {
+		case <-f.done:
+			return f.report, f.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	f := &flight{done: make(chan struct{})}
+	r.flight = f
+	r.runMu.Unlock()
+
+	defer func() {
+		// A run aborted by a panic under PanicAbort must not appear to
+		// have succeeded to the joining calls.
+		p := recover()
+		if p != nil {
+			f.err = newPanicError(p)
+		}
+		r.runMu.Lock()
+		r.flight = nil
+		r.runMu.Unlock()
+		close(f.done)
+		if p != nil {
+			panic(p)
+		}
+	}()
+
+	f.report, f.err = r.execute(ctx, cfg)
+	return f.report, f.err
+}
@@ -0,0 +1,47 @@
+package hook
+
+import (
+	"context"
+	"sync"
+)
+
+// ResultFunc is a hook function that produces a value of type T.
+type ResultFunc[T any] func(context.Context) (T, error)
+
+// RunCollect runs the given functions concurrently with the same semantics
+// and options as Registry.Run, and returns the values they produced in the
+// order of funcs. The value of a function that fails or panics is the zero
+// value of T; its error is included in the returned error.
+//
+// It is useful for hooks whose results are aggregated by the caller, such
+// as the number of drained messages or flushed bytes.
+func RunCollect[T any](ctx context.Context, funcs []ResultFunc[T], opts ...Option) ([]T, error) {
+	var (
+		mu      sync.Mutex
+		results = make([]T, len(funcs))
+		done    bool
+	)
+
+	r := New()
+	for i, fn := range funcs {
+		r.Add(func(ctx context.Context) error {
+			v, err := fn(ctx)
+
+			// A function abandoned by WithGracePeriod may return after
+			// RunCollect, at which point its value is discarded.
+			mu.Lock()
+			defer mu.Unlock()
+			if !done {
+				results[i] = v
+			}
+			return err
+		})
+	}
+
+	err := r.Run(ctx, opts...)
+
+	mu.Lock()
+	defer mu.Unlock()
+	done = true
+	return results, err
+}
@@ -0,0 +1,64 @@
+package hook
+
+import (
+	"context"
+	"slices"
+)
+
+// AsHook returns a HookFunc that runs r with the given options. It allows a
+// registry to be registered as a single hook inside another registry.
+func (r *Registry) AsHook(opts ...Option) HookFunc {
+	return func(ctx context.Context) error {
+		return r.Run(ctx, opts...)
+	}
+}
+
+// Child creates a new Registry with the same options as r, followed by opts,
+// and registers it as a single hook of r, so that running r also runs the
+// child. This lets every module of an application own a registry while still
+// being shut down by the parent. The hooks of the child run with its own
+// settings, such as WithSequential, WithRunTimeout or WithRetry, and
+// children can be nested to build trees of cleanup steps.
+func (r *Registry) Child(opts ...Option) *Registry {
+	c := New(append(slices.Clip(r.opts), opts...)...)
+	r.Add(c.AsHook())
+	return c
+}
+
+// Merge registers copies of all hook functions of other with r, keeping
+// their names, priorities and dependencies. Middleware of other is not
+// copied. The returned Token can be used to remove the merged hooks again.
+func (r *Registry) Merge(other *Registry) Token {
+	// The entries are cloned, since add stamps call sites on them and the
+	// slice loaded from other is shared with its readers.
+	return r.add(slices.Clone(other.load())...)
+}
+
+// Clone returns an independent copy of r with the same hooks, middleware and
+// options. Changes to either registry, including hooks consumed by runs, do
+// not affect the other. The history and run state of r are not copied.
+func (r *Registry) Clone() *Registry {
+	c := New(r.opts...)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Clipping makes the first registration with c reallocate, instead of
+	// appending to the array shared with r.
+	c.store(slices.Clip(r.load()))
+	c.lastID = r.lastID
+	if p := r.middleware.Load(); p != nil {
+		c.middleware.Store(p)
+	}
+	return c
+}
+
+// Snapshot returns a sealed copy of r, which captures the hooks registered at
+// the time of the call. It lets tests and canary runs execute the hooks
+// without consuming them from, or being affected by later changes to, the
+// live registry. Hooks registered with AddOnce are still removed from the
+// snapshot by its first run.
+func (r *Registry) Snapshot() *Registry {
+	c := r.Clone()
+	c.Seal()
+	return c
+}
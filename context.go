@@ -0,0 +1,46 @@
+package hook
+
+import "context"
+
+// nameKey is the context key for the name of the running hook.
+type nameKey struct{}
+
+// NameFromContext returns the name of the hook that is being run with ctx,
+// or an empty string if the hook is unnamed or ctx does not belong to a hook.
+// It allows middleware to identify the hook it wraps.
+func NameFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(nameKey{}).(string)
+	return name
+}
+
+// withName returns a context carrying the name of the hook about to be run.
+// The name is set even if it is empty, so that an unnamed hook run by a
+// nested registry does not inherit the name of the enclosing hook.
+func withName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, nameKey{}, name)
+}
+
+// registryKey is the context key for the Registry of the current scope.
+type registryKey struct{}
+
+// WithContext returns a copy of ctx carrying r, so that code called with the
+// context can register cleanup with the registry of the current scope, such
+// as a request, without resorting to global state.
+func WithContext(ctx context.Context, r *Registry) context.Context {
+	return context.WithValue(ctx, registryKey{}, r)
+}
+
+// FromContext returns the Registry carried by ctx, or nil if there is none.
+func FromContext(ctx context.Context) *Registry {
+	r, _ := ctx.Value(registryKey{}).(*Registry)
+	return r
+}
+
+// FromContextOrDefault is like FromContext, but returns the default
+// Registry if ctx carries none, so that the result is never nil.
+func FromContextOrDefault(ctx context.Context) *Registry {
+	if r := FromContext(ctx); r != nil {
+		return r
+	}
+	return Default()
+}
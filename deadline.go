@@ -0,0 +1,17 @@
+package hook
+
+import "errors"
+
+// ErrNoDeadline is returned by Run when WithRequireDeadline is in effect and
+// the context has no deadline.
+var ErrNoDeadline = errors.New("run requires a context with a deadline")
+
+// WithRequireDeadline makes Run return ErrNoDeadline without running any
+// hook if the context has no deadline and WithRunTimeout is not in effect.
+// A shutdown without a deadline hangs as long as any hook does, so this
+// catches contexts that were not given one by mistake.
+func WithRequireDeadline() Option {
+	return func(c *config) {
+		c.requireDeadline = true
+	}
+}
@@ -0,0 +1,143 @@
+package hook
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// DebugHandler returns an http.Handler that reports the state of r as JSON:
+// the registered hooks in registration order, whether a run is in progress
+// and the report of the most recent run. It lets operators inspect what a
+// process will do on shutdown and how its last run went. Like the handlers
+// of net/http/pprof, it should only be served on an internal address.
+func (r *Registry) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hooks := r.Hooks()
+		state := debugState{
+			Hooks:   make([]debugHook, len(hooks)),
+			Running: r.IsRunning(),
+			LastRun: newDebugReport(r.LastReport()),
+		}
+		for i, h := range hooks {
+			state.Hooks[i] = newDebugHook(h)
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(state)
+	})
+}
+
+// debugState is the document served by DebugHandler.
+type debugState struct {
+	Hooks   []debugHook  `json:"hooks"`
+	Running bool         `json:"running"`
+	LastRun *debugReport `json:"last_run,omitempty"`
+}
+
+// debugHook is the JSON form of a HookInfo.
+type debugHook struct {
+	Name           string   `json:"name,omitempty"`
+	Index          int      `json:"index"`
+	Priority       int      `json:"priority"`
+	Site           string   `json:"site,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+	After          []string `json:"after,omitempty"`
+	BestEffort     bool     `json:"best_effort,omitempty"`
+	MustRun        bool     `json:"must_run,omitempty"`
+	MustRunTimeout string   `json:"must_run_timeout,omitempty"`
+	Once           bool     `json:"once,omitempty"`
+	Delay          string   `json:"delay,omitempty"`
+	Serial         string   `json:"serial,omitempty"`
+}
+
+// newDebugHook converts h to its JSON form.
+func newDebugHook(h HookInfo) debugHook {
+	d := debugHook{
+		Name:       h.Name,
+		Index:      h.Index,
+		Priority:   h.Priority,
+		Site:       h.Site,
+		Tags:       h.Tags,
+		After:      h.After,
+		BestEffort: h.BestEffort,
+		MustRun:    h.MustRun,
+		Once:       h.Once,
+		Serial:     h.Serial,
+	}
+	if h.MustRunTimeout > 0 {
+		d.MustRunTimeout = h.MustRunTimeout.String()
+	}
+	if h.Delay > 0 {
+		d.Delay = h.Delay.String()
+	}
+	return d
+}
+
+// debugReport is the JSON form of a Report.
+type debugReport struct {
+	Start      time.Time     `json:"start"`
+	Duration   string        `json:"duration"`
+	Hooks      []debugResult `json:"hooks"`
+	Warnings   []string      `json:"warnings,omitempty"`
+	Incomplete string        `json:"incomplete,omitempty"`
+}
+
+// debugResult is the JSON form of a HookResult.
+type debugResult struct {
+	Name      string   `json:"name,omitempty"`
+	Index     int      `json:"index"`
+	Priority  int      `json:"priority"`
+	Site      string   `json:"site,omitempty"`
+	Duration  string   `json:"duration"`
+	Budget    string   `json:"budget,omitempty"`
+	Error     string   `json:"error,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+	Panicked  bool     `json:"panicked,omitempty"`
+	Completed bool     `json:"completed"`
+	Skipped   bool     `json:"skipped,omitempty"`
+}
+
+// newDebugReport converts report to its JSON form. It returns nil if report
+// is nil.
+func newDebugReport(report *Report) *debugReport {
+	if report == nil {
+		return nil
+	}
+	d := &debugReport{
+		Start:    report.Start,
+		Duration: report.Duration.String(),
+		Hooks:    make([]debugResult, len(report.Hooks)),
+	}
+	for i, h := range report.Hooks {
+		res := debugResult{
+			Name:      h.Name,
+			Index:     h.Index,
+			Priority:  h.Priority,
+			Site:      h.Site,
+			Duration:  h.Duration.String(),
+			Panicked:  h.Panic != nil,
+			Completed: h.Completed,
+			Skipped:   h.Skipped,
+		}
+		if h.Budget > 0 {
+			res.Budget = h.Budget.String()
+		}
+		if h.Err != nil {
+			res.Error = h.Err.Error()
+		}
+		for _, err := range h.Warnings {
+			res.Warnings = append(res.Warnings, err.Error())
+		}
+		d.Hooks[i] = res
+	}
+	for _, err := range report.Warnings {
+		d.Warnings = append(d.Warnings, err.Error())
+	}
+	if report.Incomplete != nil {
+		d.Incomplete = report.Incomplete.Error()
+	}
+	return d
+}
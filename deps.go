@@ -0,0 +1,85 @@
+package hook
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrDependencyCycle is returned by Run when the dependencies declared with
+// After cannot be satisfied.
+var ErrDependencyCycle = errors.New("dependency cycle")
+
+// After makes the hook wait until all hooks registered under the given names
+// have returned. Hooks without dependencies between them still run
+// concurrently. Names that are not registered are ignored, and a dependency
+// on a hook with a lower priority is reported as a cycle, since priorities
+// already require that hook to run later.
+func After(names ...string) HookOption {
+	return func(e *entry) {
+		e.after = append(e.after, names...)
+	}
+}
+
+// checkDependencies verifies that the dependencies of the given hooks, which
+// must be sorted by descending priority, form no cycle.
+func checkDependencies(hooks []entry) error {
+	byName := make(map[string][]int)
+	for i, e := range hooks {
+		if e.name != "" {
+			byName[e.name] = append(byName[e.name], i)
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	var (
+		state = make([]int, len(hooks))
+		path  []string
+		visit func(i int) error
+	)
+	visit = func(i int) error {
+		state[i] = visiting
+		path = append(path, hooks[i].name)
+
+		for _, dep := range hooks[i].after {
+			for _, j := range byName[dep] {
+				if j == i {
+					continue
+				}
+				if hooks[j].priority < hooks[i].priority {
+					return fmt.Errorf("%w: %q must run after %q, which has a lower priority",
+						ErrDependencyCycle, hooks[i].name, dep)
+				}
+				switch state[j] {
+				case visiting:
+					start := slices.Index(path, dep)
+					return fmt.Errorf("%w: %s -> %s",
+						ErrDependencyCycle, strings.Join(path[start:], " -> "), dep)
+				case unvisited:
+					if err := visit(j); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[i] = visited
+		return nil
+	}
+
+	for i := range hooks {
+		if state[i] == unvisited && len(hooks[i].after) > 0 {
+			if err := visit(i); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
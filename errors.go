@@ -0,0 +1,87 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// HookError is the error reported for a hook function that failed. It
+// identifies the hook and wraps the error it returned, and can be retrieved
+// from the error returned by Run with errors.As.
+type HookError struct {
+	// Name is the name the hook was registered with, if any.
+	Name string
+
+	// Index is the position of the hook in the registry when the run
+	// started.
+	Index int
+
+	// Err is the error returned by the hook, or a *PanicError if it
+	// panicked.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *HookError) Error() string {
+	if e.Name != "" {
+		return fmt.Sprintf("hook %q: %v", e.Name, e.Err)
+	}
+	return fmt.Sprintf("hook #%d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// TimeoutError is the cause of the context of a hook or run being done
+// because a timeout configured with this package expired, such as the budget
+// of a hook, the timeout of a stage or the shutdown timeout of an App. It can be retrieved with
+// context.Cause from the context passed to a hook, and with errors.As from
+// the error returned by Run if a hook returned the error of its context.
+// It matches context.DeadlineExceeded with errors.Is.
+type TimeoutError struct {
+	// Limit describes what was limited, such as "hook db budget".
+	Limit string
+
+	// Timeout is the duration of the limit.
+	Timeout time.Duration
+}
+
+// Error implements the error interface.
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s %v exceeded", e.Limit, e.Timeout)
+}
+
+// Unwrap returns context.DeadlineExceeded.
+func (e *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// withTimeout returns a copy of ctx that expires after d with a
+// *TimeoutError describing limit as its cause.
+func withTimeout(ctx context.Context, d time.Duration, limit string) (context.Context, context.CancelFunc) {
+	return context.WithTimeoutCause(ctx, d, &TimeoutError{Limit: limit, Timeout: d})
+}
+
+// hookLimit describes the limit of the given kind of the hook e for a
+// *TimeoutError.
+func hookLimit(e entry, kind string) string {
+	return "hook " + hookID(e) + " " + kind
+}
+
+// timeoutCause returns the cause of ctx being done if it is a *TimeoutError
+// and err is the error of ctx, which the cause then replaces, and err
+// otherwise.
+func timeoutCause(ctx context.Context, err error) error {
+	if err == nil || err != ctx.Err() {
+		return err
+	}
+	var te *TimeoutError
+	if cause := context.Cause(ctx); errors.As(cause, &te) {
+		return cause
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+// Package event provides a typed variant of hook.Registry whose hooks receive
+// an event payload, turning the registry into a typed event dispatcher.
+//
+// A Registry[E] has the same execution semantics as hook.Registry, including
+// priorities, dependencies, panic recovery and run options:
+//
+//	r := event.New[UserCreated]()
+//	r.Add(func(ctx context.Context, e UserCreated) error {
+//		return sendWelcomeMail(ctx, e.Email)
+//	})
+//	err := r.Run(ctx, UserCreated{Email: "user@example.com"})
+package event
+
+import (
+	"context"
+
+	"github.com/gulitsky/hook"
+)
+
+// HookFunc is a function that handles an event of type E and may return an
+// error.
+type HookFunc[E any] func(context.Context, E) error
+
+// Registry manages a collection of HookFunc instances for events of type E.
+type Registry[E any] struct {
+	hooks *hook.Registry
+}
+
+// New creates a new Registry for events of type E.
+func New[E any]() *Registry[E] {
+	return &Registry[E]{hooks: hook.New()}
+}
+
+// Add registers one or more hook functions to the Registry. The returned
+// token can be used to remove them again.
+func (r *Registry[E]) Add(funcs ...HookFunc[E]) hook.Token {
+	adapted := make([]hook.HookFunc, len(funcs))
+	for i, fn := range funcs {
+		adapted[i] = r.adapt(fn)
+	}
+	return r.hooks.Add(adapted...)
+}
+
+// AddWithPriority registers a hook function with the given priority. See
+// hook.Registry.AddWithPriority.
+func (r *Registry[E]) AddWithPriority(prio int, fn HookFunc[E]) hook.Token {
+	return r.hooks.AddWithPriority(prio, r.adapt(fn))
+}
+
+// AddNamed registers a hook function under the given name. See
+// hook.Registry.AddNamed.
+func (r *Registry[E]) AddNamed(name string, fn HookFunc[E], opts ...hook.HookOption) hook.Token {
+	return r.hooks.AddNamed(name, r.adapt(fn), opts...)
+}
+
+// Remove deregisters all hook functions registered under the given name.
+// It reports whether any hook was removed.
+func (r *Registry[E]) Remove(name string) bool {
+	return r.hooks.Remove(name)
+}
+
+// Has reports whether a hook function is registered under the given name.
+func (r *Registry[E]) Has(name string) bool {
+	return r.hooks.Has(name)
+}
+
+// Use appends middleware to the Registry. See hook.Registry.Use.
+func (r *Registry[E]) Use(mw ...hook.Middleware) {
+	r.hooks.Use(mw...)
+}
+
+// Clear removes all registered hook functions from the Registry.
+func (r *Registry[E]) Clear() {
+	r.hooks.Clear()
+}
+
+// Run executes all registered hook functions with the given event. See
+// hook.Registry.Run.
+func (r *Registry[E]) Run(ctx context.Context, event E, opts ...hook.Option) error {
+	return r.hooks.Run(r.with(ctx, event), opts...)
+}
+
+// RunReport is like Run, but also returns a report describing the outcome of
+// every hook. See hook.Registry.RunReport.
+func (r *Registry[E]) RunReport(ctx context.Context, event E, opts ...hook.Option) (*hook.Report, error) {
+	return r.hooks.RunReport(r.with(ctx, event), opts...)
+}
+
+// Len returns the number of registered hook functions.
+func (r *Registry[E]) Len() int {
+	return r.hooks.Len()
+}
+
+// IsEmpty returns true if no hooks are registered.
+func (r *Registry[E]) IsEmpty() bool {
+	return r.hooks.IsEmpty()
+}
+
+// with returns a context carrying the event being dispatched. The registry
+// itself is used as the key, so registries never see each other's events.
+func (r *Registry[E]) with(ctx context.Context, event E) context.Context {
+	return context.WithValue(ctx, r, event)
+}
+
+// adapt turns fn into a hook.HookFunc that takes its event from the context.
+func (r *Registry[E]) adapt(fn HookFunc[E]) hook.HookFunc {
+	return func(ctx context.Context) error {
+		event, _ := ctx.Value(r).(E)
+		return fn(ctx, event)
+	}
+}
@@ -0,0 +1,81 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+)
+
+// Exit runs the default registry with ctx and then terminates the process
+// with the given status code. Errors from the hooks are written to standard
+// error but do not change the code. Use Exit instead of os.Exit, which skips
+// every registered hook.
+func Exit(ctx context.Context, code int) {
+	if err := Default().Run(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "hook: %v\n", err)
+	}
+	os.Exit(code)
+}
+
+// Fatal writes err to the standard logger, runs the default registry with
+// ctx and then terminates the process with status code 1. Use Fatal instead
+// of log.Fatal, which skips every registered hook.
+func Fatal(ctx context.Context, err error) {
+	log.Print(err)
+	Exit(ctx, 1)
+}
+
+// Main runs fn as the body of a program and exits the process once it
+// returns, after running the default registry with a background context.
+// The status code is 0 if fn returns nil and 1 if it returns an error, which
+// is written to the standard logger. If fn panics, the panic and its stack
+// trace are written to standard error and the status code is 2, as for an
+// unrecovered panic.
+//
+// Main is meant to be called as the only statement of the main function:
+//
+//	func main() {
+//		hook.Main(run)
+//	}
+func Main(fn func(context.Context) error) {
+	ctx := context.Background()
+	code := 0
+
+	func() {
+		defer func() {
+			if p := recover(); p != nil {
+				fmt.Fprintf(os.Stderr, "panic: %v\n\n%s", p, debug.Stack())
+				code = 2
+			}
+		}()
+
+		if err := fn(ctx); err != nil {
+			log.Print(err)
+			code = 1
+		}
+	}()
+
+	Exit(ctx, code)
+}
+
+// ExitCodeFunc maps the error of starting or shutting down a process to the
+// status code the process exits with, so that orchestrators can tell failure
+// modes apart.
+type ExitCodeFunc func(error) int
+
+// ExitCode is the default ExitCodeFunc. It returns 0 if err is nil, 2 if the
+// shutdown timed out, that is if err matches context.DeadlineExceeded or
+// ErrStuck, and 1 for any other failure.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, ErrStuck):
+		return 2
+	default:
+		return 1
+	}
+}
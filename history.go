@@ -0,0 +1,60 @@
+package hook
+
+import "sync"
+
+// history is a ring buffer holding the reports of the most recent runs of a
+// Registry.
+type history struct {
+	mu      sync.Mutex
+	reports []*Report
+	next    int
+	full    bool
+}
+
+// WithHistory makes a Registry keep the reports of its last n runs, which
+// can be retrieved with History. This is useful for debug endpoints and for
+// analyzing repeated runs after an incident. It only has an effect when
+// passed to New.
+func WithHistory(n int) Option {
+	return func(c *config) {
+		c.history = n
+	}
+}
+
+// add records report, replacing the oldest one if the buffer is full. It
+// does nothing if h is nil.
+func (h *history) add(report *Report) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.reports[h.next] = report
+	h.next = (h.next + 1) % len(h.reports)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// History returns the reports of the most recent runs of the Registry,
+// oldest first. Only runs that executed hooks are recorded. It returns nil
+// unless WithHistory was passed to New. The reports must not be modified.
+func (r *Registry) History() []*Report {
+	h := r.history
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.full {
+		return append([]*Report(nil), h.reports[:h.next]...)
+	}
+	return append(append([]*Report(nil), h.reports[h.next:]...), h.reports[:h.next]...)
+}
+
+// LastReport returns the report of the most recent run of the Registry that
+// executed hooks, or nil if there was none. Unlike History, it does not
+// require WithHistory. The report must not be modified.
+func (r *Registry) LastReport() *Report {
+	return r.last.Load()
+}
@@ -4,10 +4,14 @@
 package hook
 
 import (
+	"cmp"
 	"context"
 	"errors"
-	"fmt"
+	"runtime/trace"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // HookFunc is a function that performs an operation with a context and may
@@ -17,110 +21,1098 @@ type HookFunc func(context.Context) error
 // Registry manages a collection of HookFunc instances that can be executed
 // concurrently.
 type Registry struct {
-	mu    sync.Mutex
-	hooks []HookFunc
+	// hooks and middleware are copy-on-write: a published slice is never
+	// modified, so that runs and readers can load it without locking. mu
+	// serializes the writers.
+	mu         sync.Mutex
+	hooks      atomic.Pointer[[]entry]
+	middleware atomic.Pointer[[]Middleware]
+	lastID     uint64
+
+	// runMu guards the state of the runs in progress below, so that
+	// starting a run does not contend with registering hooks.
+	runMu sync.Mutex
+
+	// flight is the coalescing run in progress, if any.
+	flight *flight
+
+	// running counts the runs in progress; idle is closed once it drops
+	// back to zero.
+	running int
+	idle    chan struct{}
+
+	// opts are the options passed to New, applied to every run before the
+	// options passed to Run.
+	opts []Option
+
+	// history records the reports of past runs if WithHistory was passed to
+	// New, and last the report of the most recent one.
+	history *history
+	last    atomic.Pointer[Report]
+
+	// callSites and strictNames are set if WithCallSites and
+	// WithStrictNames were passed to New.
+	callSites   bool
+	strictNames bool
+
+	// sealed is set by Seal. It is only set with mu held.
+	sealed atomic.Bool
+
+	// initiated is set when the first run begins.
+	initiated latch
+
+	// observers are the functions registered with Observe.
+	observers    []observer
+	lastObserver uint64
 }
 
-var (
-	defaultOnce     sync.Once
-	defaultRegistry *Registry
-)
+// entry is a registered hook function along with its optional name and
+// scheduling constraints.
+type entry struct {
+	id       uint64
+	name     string
+	priority int
+	after    []string
+	tags     []string
+	fn       HookFunc
+
+	// site is the location the hook was registered from, if WithCallSites
+	// was passed to New.
+	site string
+
+	// bestEffort marks a hook whose failure is only a warning.
+	bestEffort bool
+
+	// once marks a hook that is removed by the first run that selects it.
+	once bool
+
+	// replace marks a hook that replaces the hooks registered under its
+	// name.
+	replace bool
+
+	// mustRun marks a hook that runs with a detached context limited to
+	// mustRunTimeout.
+	mustRun        bool
+	mustRunTimeout time.Duration
+
+	// delay is the time from the start of a run before the hook is called.
+	delay time.Duration
+
+	// serial is the key of the hooks the hook must not run concurrently
+	// with, if any.
+	serial string
+
+	// index is the position of the hook in the registry when a run starts.
+	// It is only set on the copies taken by the run.
+	index int
+}
+
+// defaultRegistry holds the process-wide Registry returned by Default.
+var defaultRegistry atomic.Pointer[Registry]
 
 // New creates a new Registry for managing hook functions.
-// The registry is initialized with a pre-allocated slice to optimize memory
-// usage.
-func New() *Registry {
-	return &Registry{
-		hooks: make([]HookFunc, 0, 10),
+//
+// The given options become the defaults for every run of the registry, and
+// can be overridden by passing options to Run. In addition, WithCapacity
+// sets the number of hooks the registry pre-allocates space for, which is 10
+// by default, and WithHistory enables recording the reports of past runs.
+func New(opts ...Option) *Registry {
+	cfg := newConfig(opts)
+	if cfg.capacity <= 0 {
+		cfg.capacity = 10
+	}
+
+	r := &Registry{
+		opts:        opts,
+		callSites:   cfg.callSites,
+		strictNames: cfg.strictNames,
+	}
+	if cfg.history > 0 {
+		r.history = &history{reports: make([]*Report, cfg.history)}
+	}
+	hooks := make([]entry, 0, cfg.capacity)
+	r.hooks.Store(&hooks)
+	return r
+}
+
+// load returns the registered hooks. The returned slice must not be
+// modified.
+func (r *Registry) load() []entry {
+	if p := r.hooks.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// store publishes hooks as the registered hooks. It must be called with r.mu
+// held, and hooks must not be modified afterwards.
+func (r *Registry) store(hooks []entry) {
+	r.hooks.Store(&hooks)
+}
+
+// config returns the configuration for a run with the given options.
+func (r *Registry) config(opts []Option) config {
+	if len(r.opts) == 0 {
+		return newConfig(opts)
 	}
+	return newConfig(slices.Concat(r.opts, opts))
 }
 
 // Default returns a singleton Registry instance, creating it if necessary.
 // It is safe for concurrent use.
 func Default() *Registry {
-	defaultOnce.Do(func() {
-		defaultRegistry = New()
+	if r := defaultRegistry.Load(); r != nil {
+		return r
+	}
+	defaultRegistry.CompareAndSwap(nil, New())
+	return defaultRegistry.Load()
+}
+
+// SetDefault makes r the Registry returned by Default. Hooks registered with
+// the previous default registry are not transferred. It is intended for tests
+// and dependency injection containers that need to isolate or provide the
+// process-wide registry. It panics if r is nil.
+func SetDefault(r *Registry) {
+	if r == nil {
+		panic("hook: SetDefault called with nil Registry")
+	}
+	defaultRegistry.Store(r)
+}
+
+// ResetDefault discards the current default Registry, so that the next call
+// to Default creates a new, empty one.
+func ResetDefault() {
+	defaultRegistry.Store(nil)
+}
+
+// Add registers one or more hook functions to the Registry. The returned
+// Token can be used to remove them again.
+func (r *Registry) Add(funcs ...HookFunc) Token {
+	entries := make([]entry, len(funcs))
+	for i, fn := range funcs {
+		entries[i] = entry{fn: fn}
+	}
+	return r.add(entries...)
+}
+
+// AddWithPriority registers a hook function with the given priority.
+// Hooks with a higher priority are run before hooks with a lower priority;
+// hooks registered with Add or AddNamed have priority 0.
+func (r *Registry) AddWithPriority(prio int, fn HookFunc) Token {
+	return r.add(entry{priority: prio, fn: fn})
+}
+
+// AddNamed registers a hook function under the given name, so that it can
+// later be deregistered with Remove or referenced by other hooks. Names are
+// not required to be unique unless WithStrictNames is in effect. The hook can
+// be further configured with options such as After and Replace.
+func (r *Registry) AddNamed(name string, fn HookFunc, opts ...HookOption) Token {
+	e := entry{name: name, fn: fn}
+	for _, opt := range opts {
+		opt(&e)
+	}
+	return r.add(e)
+}
+
+// Register registers a hook function configured with the given options, such
+// as WithTags. The returned Token can be used to remove it again.
+func (r *Registry) Register(fn HookFunc, opts ...HookOption) Token {
+	e := entry{fn: fn}
+	for _, opt := range opts {
+		opt(&e)
+	}
+	return r.add(e)
+}
+
+// AddOnce registers a hook function that is run only once: the first run
+// that selects it removes it from the Registry as the run starts, like
+// WithConsumeOnRun does for all hooks, while the other hooks are retained.
+// This suits registries that are run repeatedly, such as event dispatchers,
+// but contain one-shot work. The hook can be further configured with options.
+func (r *Registry) AddOnce(fn HookFunc, opts ...HookOption) Token {
+	e := entry{fn: fn, once: true}
+	for _, opt := range opts {
+		opt(&e)
+	}
+	return r.add(e)
+}
+
+// add assigns ids to the given entries and appends them to the Registry. It
+// panics if r is sealed, or if WithStrictNames is in effect and a name is
+// already taken.
+func (r *Registry) add(entries ...entry) Token {
+	t, err := r.insert(r.strictNames, entries)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// insert assigns ids to the given entries and appends them to the Registry.
+// Hooks registered under the name of an entry marked with Replace are
+// removed. The entries are modified, so they must not be shared with another
+// registry. It fails with ErrSealed if r is sealed and, if strict is set, with
+// ErrDuplicateName instead of registering a name that is already taken.
+func (r *Registry) insert(strict bool, entries []entry) (Token, error) {
+	if r.callSites {
+		site := callSite()
+		for i := range entries {
+			if entries[i].site == "" {
+				entries[i].site = site
+			}
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.sealed.Load() {
+		return Token{}, ErrSealed
+	}
+
+	// Appending is safe even if it does not reallocate: readers of the
+	// current slice never look beyond its length, and every other writer
+	// publishes a fresh copy.
+	old := r.load()
+	hooks := old
+	isReplaced := func(entry) bool { return false }
+	if replaced := replacedNames(entries); len(replaced) > 0 {
+		isReplaced = func(e entry) bool {
+			return slices.Contains(replaced, e.name)
+		}
+		hooks = slices.DeleteFunc(slices.Clone(hooks), isReplaced)
+	}
+	if strict {
+		if err := checkNames(hooks, entries); err != nil {
+			return Token{}, err
+		}
+	}
+
+	t := Token{r: r, first: r.lastID + 1}
+	for _, e := range entries {
+		r.lastID++
+		e.id = r.lastID
+		hooks = append(hooks, e)
+	}
+	t.last = r.lastID
+	r.store(hooks)
+	r.notify(HooksRemoved, old, isReplaced)
+	r.notify(HooksAdded, entries, nil)
+	return t, nil
+}
+
+// Remove deregisters all hook functions registered under the given name.
+// It reports whether any hook was removed.
+func (r *Registry) Remove(name string) bool {
+	if name == "" {
+		return false
+	}
+	return r.removeFunc(func(e entry) bool {
+		return e.name == name
 	})
-	return defaultRegistry
 }
 
-// Add registers one or more hook functions to the Registry.
-func (r *Registry) Add(funcs ...HookFunc) {
+// removeFunc deregisters all hook functions for which del returns true.
+// It reports whether any hook was removed, and panics if r is sealed.
+func (r *Registry) removeFunc(del func(entry) bool) bool {
+	ok, err := r.tryRemoveFunc(del)
+	if err != nil {
+		panic(err)
+	}
+	return ok
+}
+
+// tryRemoveFunc is like removeFunc, but returns ErrSealed if r is sealed.
+func (r *Registry) tryRemoveFunc(del func(entry) bool) (bool, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.hooks = append(r.hooks, funcs...)
+
+	if r.sealed.Load() {
+		return false, ErrSealed
+	}
+	hooks := r.load()
+	if !slices.ContainsFunc(hooks, del) {
+		return false, nil
+	}
+	r.store(slices.DeleteFunc(slices.Clone(hooks), del))
+	r.notify(HooksRemoved, hooks, del)
+	return true, nil
+}
+
+// Has reports whether a hook function is registered under the given name.
+func (r *Registry) Has(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, e := range r.load() {
+		if e.name == name {
+			return true
+		}
+	}
+	return false
 }
 
 // Clear removes all registered hook functions from the Registry.
-// It is safe for concurrent use.
+// It is safe for concurrent use. It panics if r is sealed.
 func (r *Registry) Clear() {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	r.hooks = r.hooks[:0]
+	if err := r.TryClear(); err != nil {
+		panic(err)
+	}
 }
 
 // Run executes all registered hook functions concurrently with the provided context.
-// The hooks remain in the registry after execution, allowing for repeated runs.
+// By default, the hooks remain in the registry after execution, allowing for
+// repeated runs; see WithConsumeOnRun for one-shot semantics.
 //
-// The functions are executed in reverse order of registration to support LIFO
-// semantics, which is common for resource cleanup (e.g., closing resources
-// in the opposite order of their creation).
+// Hooks are grouped by priority and the groups are run one after another,
+// highest priority first; each group waits for the previous one to finish.
+// Within a group, the functions are started in reverse order of registration
+// to support LIFO semantics, which is common for resource cleanup (e.g.,
+// closing resources in the opposite order of their creation). WithOrder can
+// be used to start them in registration order instead.
 //
-// If the context is already canceled, Run returns the context's error immediately.
+// If the context is already canceled, Run returns the context's error
+// immediately, unless hooks marked with MustRun are registered, which are
+// then run on their own.
 // Any errors or panics from the hook functions are collected and returned as a
-// single error using errors.Join.
-func (r *Registry) Run(ctx context.Context) error {
-	r.mu.Lock()
-	hooks := make([]HookFunc, len(r.hooks))
-	copy(hooks, r.hooks)
-	r.mu.Unlock()
+// single error using errors.Join. Each error is wrapped in a *HookError
+// identifying the hook, and the errors are listed in the order the hooks are
+// scheduled rather than the order they finished, so the result is
+// reproducible across runs. If the context is done before all hooks have
+// returned, the error also includes an *IncompleteError listing the hooks
+// that were still running or not yet started at that moment. The behavior of
+// a run can be adjusted with options such as WithMaxConcurrency, and
+// WithPanicPolicy makes panics propagate instead of being collected.
+func (r *Registry) Run(ctx context.Context, opts ...Option) error {
+	_, err := r.run(ctx, r.config(opts))
+	return err
+}
+
+// RunFailFast is like Run, but cancels the context passed to the hooks as
+// soon as the first hook returns an error or panics. Hooks that have not been
+// started by then, including those of lower priority, are skipped. This is
+// useful for startup hooks, where there is no point continuing once one of
+// them has failed.
+func (r *Registry) RunFailFast(ctx context.Context, opts ...Option) error {
+	cfg := r.config(opts)
+	cfg.failFast = true
+	_, err := r.run(ctx, cfg)
+	return err
+}
+
+// RunReport is like Run, but also returns a Report describing the outcome
+// of every hook. The report is nil if no hooks are registered or the context
+// is already canceled.
+func (r *Registry) RunReport(ctx context.Context, opts ...Option) (*Report, error) {
+	return r.run(ctx, r.config(opts))
+}
+
+// run implements Run and its variants.
+func (r *Registry) run(ctx context.Context, cfg config) (*Report, error) {
+	if _, ok := ctx.Deadline(); !ok && cfg.requireDeadline && cfg.runTimeout <= 0 {
+		return nil, ErrNoDeadline
+	}
+
+	r.begin()
+	defer r.end()
+
+	if cfg.runTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = withTimeout(ctx, cfg.runTimeout, "run timeout")
+		defer cancel()
+	}
+
+	var (
+		report *Report
+		err    error
+	)
+	if cfg.coalesce {
+		report, err = r.coalesced(ctx, cfg)
+	} else {
+		report, err = r.execute(ctx, cfg)
+	}
+
+	if cfg.panicPolicy == PanicRepanic && report != nil {
+		if res := report.firstPanic(); res != nil {
+			panic(res.Panic)
+		}
+	}
+	return report, err
+}
+
+// execute performs a single run of the registry.
+func (r *Registry) execute(ctx context.Context, cfg config) (*Report, error) {
+	// Consumed hooks are only removed once their dependencies have been
+	// checked, so that a run failing with a cycle leaves them registered.
+	registered := r.load()
+	var (
+		hooks []entry
+		err   error
+	)
+	if ctx.Err() == nil && slices.ContainsFunc(registered, cfg.consumes) {
+		r.mu.Lock()
+		registered = r.load()
+		hooks, err = cfg.schedule(registered)
+		if err == nil {
+			r.store(slices.DeleteFunc(slices.Clone(registered), cfg.consumes))
+			r.notify(HooksRemoved, registered, cfg.consumes)
+		}
+		r.mu.Unlock()
+	} else {
+		hooks, err = cfg.schedule(registered)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var middleware []Middleware
+	if p := r.middleware.Load(); p != nil {
+		middleware = slices.Clip(*p)
+	}
+
+	if cfg.retryAttempts > 1 {
+		middleware = append(middleware, Retry(cfg.retryAttempts, cfg.retryBackoff))
+	}
 
 	if len(hooks) == 0 {
-		return nil
+		return nil, nil
+	}
+
+	expired := ctx.Err() != nil
+	if expired && !slices.ContainsFunc(hooks, func(e entry) bool { return e.mustRun }) {
+		return nil, context.Cause(ctx)
+	}
+
+	if cfg.order == LIFO {
+		hooks = reverse(hooks)
+	}
+
+	if trace.IsEnabled() {
+		var task *trace.Task
+		ctx, task = trace.NewTask(ctx, "hook.Run")
+		defer task.End()
+	}
+
+	rn := &runner{
+		cfg:        cfg,
+		middleware: middleware,
+		observers:  cfg.hookObservers(),
+		expired:    expired,
+		report: &Report{
+			Start: time.Now(),
+			Hooks: make([]HookResult, 0, len(hooks)),
+		},
+	}
+	rn.ctx = ctx
+	if cfg.graceful > 0 {
+		rn.parent = ctx
+		var cancel context.CancelFunc
+		ctx, cancel = withTimeout(ctx, cfg.graceful, "grace period")
+		defer cancel()
+		rn.grace = ctx
+		defer rn.endForce()
+	}
+	if cfg.failFast || cfg.cancelOnError {
+		var cancel context.CancelCauseFunc
+		ctx, cancel = context.WithCancelCause(ctx)
+		defer cancel(nil)
+		rn.cancel = cancel
+	} else if cfg.panicPolicy == PanicAbort {
+		// The hooks still running when the run is aborted are canceled as
+		// the panic unwinds.
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+	}
+
+	hookErrs := make([]error, 0, len(hooks))
+	for len(hooks) > 0 {
+		n := 1
+		for n < len(hooks) && hooks[n].priority == hooks[0].priority {
+			n++
+		}
+		rn.later = hooks[n:]
+		if rn.incomplete == nil && rn.ctx.Err() != nil {
+			rn.interrupt(nil, hooks[:n])
+		}
+
+		if rn.abandoned {
+			n = len(hooks)
+			hookErrs = append(hookErrs, rn.skip(hooks)...)
+		} else {
+			budget := rn.budget(ctx, hooks)
+			hookErrs = append(hookErrs, rn.group(ctx, hooks[:n], budget)...)
+		}
+		hooks = hooks[n:]
+	}
+
+	if rn.incomplete != nil {
+		rn.report.Incomplete = rn.incomplete
+		hookErrs = append(hookErrs, rn.incomplete)
+	}
+
+	rn.report.Duration = time.Since(rn.report.Start)
+	r.history.add(rn.report)
+	r.last.Store(rn.report)
+	for _, o := range rn.observers {
+		o.runFinished(rn.report)
+	}
+	return rn.report, errors.Join(hookErrs...)
+}
+
+// schedule returns the hooks of registered selected for a run, sorted by
+// descending priority, after verifying that their dependencies form no
+// cycle.
+func (c *config) schedule(registered []entry) ([]entry, error) {
+	hooks := make([]entry, 0, len(registered))
+	for i, e := range registered {
+		if c.selects(e) {
+			e.index = i
+			hooks = append(hooks, e)
+		}
 	}
 
-	if err := ctx.Err(); err != nil {
-		return err
+	// A stable sort keeps registration order within a priority, so every
+	// priority group can still be reversed for LIFO semantics.
+	slices.SortStableFunc(hooks, func(a, b entry) int {
+		return cmp.Compare(b.priority, a.priority)
+	})
+
+	if err := checkDependencies(hooks); err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+// runner holds the state shared by the hooks of a single run.
+type runner struct {
+	cfg        config
+	middleware []Middleware
+	report     *Report
+	observers  []hookObserver
+
+	// cancel, if set, cancels the run on the first hook failure.
+	cancel context.CancelCauseFunc
+
+	// expired is set if the context was already done when the run started,
+	// in which case only hooks marked with MustRun are started.
+	expired bool
+
+	// ctx is the context the run was started with. Once it is done, the
+	// hooks that had not returned yet are recorded in incomplete. later
+	// holds the hooks of the groups after the current one.
+	ctx        context.Context
+	incomplete *IncompleteError
+	later      []entry
+
+	// In two-phase mode, parent is the context the run was started with and
+	// grace the context of the graceful phase. Once grace is done, force
+	// holds the context of the forced phase, and the run is abandoned when
+	// force is done as well.
+	parent, grace, force context.Context
+	forceCancel          context.CancelFunc
+	abandoned            bool
+}
+
+// group executes the given hooks concurrently and returns the errors they
+// produced. Hooks are started in the given order, except that a hook
+// declaring dependencies with After is not started before all of them have
+// returned, and a hook with a Serial key is not started while another hook
+// with the same key is running. A result for every hook is appended to the report in the order
+// the hooks were started, while the errors are returned in the given order
+// regardless of when the hooks finished. If budget is positive, it limits
+// the time every hook may take.
+func (rn *runner) group(ctx context.Context, hooks []entry, budget time.Duration) []error {
+	n := len(hooks)
+	if n == 1 && rn.grace == nil {
+		return rn.single(ctx, hooks[0], budget)
+	}
+	if rn.unscheduled(hooks) {
+		return rn.parallel(ctx, hooks, budget)
 	}
 
+	// Hooks are addressed by their position p in hooks.
 	var (
-		wg      sync.WaitGroup
-		errChan = make(chan error, len(hooks))
+		results    = make([]HookResult, n)
+		finished   = make([]bool, n)
+		startedAt  = make([]time.Time, n)
+		pending    = make([]int, n)
+		dependents = make([][]int, n)
+		ready      = make([]int, 0, n)
+		started    = make([]int, 0, n)
 	)
+	for p := range n {
+		results[p] = newResult(hooks[p])
+	}
+	if slices.ContainsFunc(hooks, func(e entry) bool { return len(e.after) > 0 }) {
+		byName := make(map[string][]int)
+		for p, e := range hooks {
+			if e.name != "" {
+				byName[e.name] = append(byName[e.name], p)
+			}
+		}
+		for p, e := range hooks {
+			for _, dep := range e.after {
+				for _, q := range byName[dep] {
+					if q != p {
+						pending[p]++
+						dependents[q] = append(dependents[q], p)
+					}
+				}
+			}
+		}
+	}
+	for p := range n {
+		if pending[p] == 0 {
+			ready = append(ready, p)
+		}
+	}
 
-	wg.Add(len(hooks))
+	limit := n
+	if rn.cfg.maxConcurrency > 0 {
+		limit = rn.cfg.maxConcurrency
+	}
 
-	for i := len(hooks) - 1; i >= 0; i-- {
-		go func(fn HookFunc) {
-			defer wg.Done()
-			defer func() {
-				if r := recover(); r != nil {
-					errChan <- fmt.Errorf("hook function panic: %v", r)
+	release := func(p int) {
+		for _, d := range dependents[p] {
+			if pending[d]--; pending[d] == 0 {
+				ready = append(ready, d)
+			}
+		}
+	}
+
+	var (
+		// Every hook stores its result in its own slot and then sends its
+		// position on done. Slots are only read after the position has been
+		// received, so hooks abandoned in two-phase mode may still write
+		// theirs; done is buffered for them to not block.
+		slots   = make([]HookResult, n)
+		done    = make(chan int, n)
+		running int
+
+		// busy holds the serial keys of the running hooks. A ready hook
+		// whose key is busy waits for the hook holding it to return.
+		busy      map[string]bool
+		available = func(p int) bool {
+			return hooks[p].serial == "" || !busy[hooks[p].serial]
+		}
+
+		// head is the position in ready of the next hook to consider.
+		// Hooks are taken from the front unless a serial key holds them
+		// back, so the queue is only compacted in that case.
+		head int
+		take = func(i int) {
+			if i == head {
+				head++
+			} else {
+				ready = slices.Delete(ready, i, i+1)
+			}
+		}
+	)
+
+	// With WithStagger, lastStart is the time the last hook was started,
+	// and wake fires once the next one may be started.
+	var lastStart time.Time
+
+loop:
+	for head < len(ready) || running > 0 {
+		var wake <-chan time.Time
+		for running < limit {
+			i := slices.IndexFunc(ready[head:], available)
+			if i < 0 {
+				break
+			}
+			i += head
+			p := ready[i]
+
+			// A hook that is not started still releases its dependents,
+			// since some of them may have to run regardless.
+			if !hooks[p].mustRun && rn.halted(ctx) {
+				take(i)
+				release(p)
+				continue
+			}
+
+			if wait := time.Until(lastStart.Add(rn.cfg.stagger)); rn.cfg.stagger > 0 && wait > 0 {
+				wake = time.After(wait)
+				break
+			}
+			take(i)
+
+			hctx := rn.phaseContext(ctx)
+			if rn.abandoned {
+				break loop
+			}
+
+			started = append(started, p)
+			startedAt[p] = time.Now()
+			lastStart = startedAt[p]
+			running++
+			if key := hooks[p].serial; key != "" {
+				if busy == nil {
+					busy = make(map[string]bool)
+				}
+				busy[key] = true
+			}
+
+			go func(e entry) {
+				slots[p] = rn.exec(hctx, e, budget)
+				done <- p
+			}(hooks[p])
+		}
+
+		if running == 0 && wake == nil {
+			break
+		}
+
+		var ctxDone, graceDone, forceDone <-chan struct{}
+		if rn.incomplete == nil {
+			ctxDone = rn.ctx.Done()
+		}
+		if rn.force != nil {
+			forceDone = rn.force.Done()
+		} else if rn.grace != nil {
+			graceDone = rn.grace.Done()
+		}
+
+		select {
+		case p := <-done:
+			rn.abort(&slots[p])
+			results[p] = slots[p]
+			finished[p] = true
+			running--
+			delete(busy, hooks[p].serial)
+			release(p)
+		case <-ctxDone:
+			var runningHooks, notStarted []entry
+			for p := range n {
+				switch {
+				case startedAt[p].IsZero():
+					notStarted = append(notStarted, hooks[p])
+				case !finished[p]:
+					runningHooks = append(runningHooks, hooks[p])
 				}
-			}()
+			}
+			rn.interrupt(runningHooks, notStarted)
+		case <-graceDone:
+			rn.phaseContext(ctx)
+		case <-forceDone:
+			rn.abandoned = true
+			break loop
+		case <-wake:
+		}
+	}
 
-			if err := fn(ctx); err != nil {
-				errChan <- err
+	if rn.abandoned {
+		for _, p := range started {
+			if !finished[p] {
+				results[p].Skipped = false
+				results[p].Duration = time.Since(startedAt[p])
+				results[p].Err = ErrStuck
 			}
-		}(hooks[i])
+		}
+		for p := range results {
+			if results[p].Skipped {
+				results[p].Err = ErrNotStarted
+			}
+		}
 	}
 
-	wg.Wait()
-	close(errChan)
+	return rn.collect(results, started)
+}
 
-	hookErrs := make([]error, 0, len(hooks))
-	for err := range errChan {
-		hookErrs = append(hookErrs, err)
+// unscheduled reports whether the hooks of a group can all be started at
+// once and awaited together, because nothing holds any of them back or
+// requires reacting to a single hook returning: no dependencies, serial
+// keys, concurrency limit, stagger, two-phase mode or PanicAbort.
+func (rn *runner) unscheduled(hooks []entry) bool {
+	c := &rn.cfg
+	if rn.grace != nil || c.maxConcurrency > 0 && c.maxConcurrency < len(hooks) ||
+		c.stagger > 0 || c.panicPolicy == PanicAbort {
+		return false
 	}
+	return !slices.ContainsFunc(hooks, func(e entry) bool {
+		return len(e.after) > 0 || e.serial != ""
+	})
+}
 
-	return errors.Join(hookErrs...)
+// parallel executes a group of hooks that are all started at once, as
+// determined by unscheduled. Every hook writes its result directly to its
+// own position in results, which is read once all hooks have returned.
+func (rn *runner) parallel(ctx context.Context, hooks []entry, budget time.Duration) []error {
+	n := len(hooks)
+	var (
+		results  = make([]HookResult, n)
+		finished = make([]atomic.Bool, n)
+		started  = make([]int, 0, n)
+		wg       sync.WaitGroup
+	)
+	for p, e := range hooks {
+		results[p] = newResult(e)
+	}
+	for p, e := range hooks {
+		if !e.mustRun && rn.halted(ctx) {
+			continue
+		}
+		started = append(started, p)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[p] = rn.exec(ctx, e, budget)
+			finished[p].Store(true)
+		}()
+	}
+
+	if rn.incomplete != nil || rn.ctx.Done() == nil {
+		wg.Wait()
+		return rn.collect(results, started)
+	}
+
+	all := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(all)
+	}()
+	select {
+	case <-all:
+	case <-rn.ctx.Done():
+		var runningHooks, notStarted []entry
+		isStarted := make([]bool, n)
+		for _, p := range started {
+			isStarted[p] = true
+		}
+		for p := range n {
+			switch {
+			case !isStarted[p]:
+				notStarted = append(notStarted, hooks[p])
+			case !finished[p].Load():
+				runningHooks = append(runningHooks, hooks[p])
+			}
+		}
+		rn.interrupt(runningHooks, notStarted)
+		<-all
+	}
+	return rn.collect(results, started)
+}
+
+// collect records the results of a group in the report, those of the hooks
+// that were started first in the order they were started, and returns the
+// errors of the failed hooks in the order of results.
+func (rn *runner) collect(results []HookResult, started []int) []error {
+	var hookErrs []error
+	for p := range results {
+		if err := rn.failure(&results[p]); err != nil {
+			hookErrs = append(hookErrs, err)
+		}
+	}
+
+	for _, p := range started {
+		rn.report.Hooks = append(rn.report.Hooks, results[p])
+	}
+	for p := range results {
+		if results[p].Skipped {
+			rn.report.Hooks = append(rn.report.Hooks, results[p])
+		}
+	}
+
+	return hookErrs
+}
+
+// single executes a group consisting of the hook e on the calling goroutine,
+// avoiding the cost of scheduling it for the common case of a registry with
+// a single hook. It is not used in two-phase mode, where a stuck hook must be
+// abandoned.
+func (rn *runner) single(ctx context.Context, e entry, budget time.Duration) []error {
+	res := newResult(e)
+	if e.mustRun || !rn.halted(ctx) {
+		res = rn.exec(ctx, e, budget)
+		rn.abort(&res)
+		if rn.incomplete == nil && rn.ctx.Err() != nil {
+			rn.interrupt([]entry{e}, nil)
+		}
+	}
+
+	rn.report.Hooks = append(rn.report.Hooks, res)
+	if err := rn.failure(&res); err != nil {
+		return []error{err}
+	}
+	return nil
+}
+
+// abort panics with the value of the panic of the hook described by res if
+// it panicked and PanicAbort is in effect.
+func (rn *runner) abort(res *HookResult) {
+	if res.Panic != nil && rn.cfg.panicPolicy == PanicAbort {
+		panic(res.Panic)
+	}
+}
+
+// failure returns the error of a failed critical hook wrapped in a
+// *HookError. The error of a failed best-effort hook, or a *Warning, is
+// recorded as a warning in the report instead, and nil is returned. The
+// warnings reported by the hook with Warn are recorded as well.
+func (rn *runner) failure(res *HookResult) error {
+	for _, w := range res.Warnings {
+		rn.report.Warnings = append(rn.report.Warnings,
+			&HookError{Name: res.Name, Index: res.Index, Err: w})
+	}
+
+	if res.Err == nil {
+		return nil
+	}
+	err := &HookError{Name: res.Name, Index: res.Index, Err: res.Err}
+	if res.BestEffort || isWarning(res.Err) {
+		rn.report.Warnings = append(rn.report.Warnings, err)
+		return nil
+	}
+	return err
+}
+
+// halted reports whether hooks not marked with MustRun may no longer be
+// started, either because the context was done when the run started, a hook
+// failed in fail-fast mode, or the context is done and WithSequential is in
+// effect.
+func (rn *runner) halted(ctx context.Context) bool {
+	return rn.expired ||
+		rn.cfg.failFast && ctx.Err() != nil ||
+		rn.cfg.stopWhenDone && rn.ctx.Err() != nil
+}
+
+// exec runs the hook e with ctx and returns its result. If budget is
+// positive, it limits the time the hook may take unless it is marked with
+// MustRun.
+func (rn *runner) exec(ctx context.Context, e entry, budget time.Duration) HookResult {
+	res := newResult(e)
+	res.Skipped = false
+
+	if e.delay > 0 {
+		wait := ctx
+		if e.mustRun {
+			wait = context.WithoutCancel(ctx)
+		}
+		if !sleep(wait, time.Until(rn.report.Start.Add(e.delay))) {
+			res.Err = ctx.Err()
+			return res
+		}
+	}
+
+	if rn.cfg.limiter != nil {
+		wait := ctx
+		if e.mustRun {
+			wait = context.WithoutCancel(ctx)
+		}
+		if err := rn.cfg.limiter.Wait(wait); err != nil {
+			res.Err = err
+			return res
+		}
+	}
+
+	var warns warnings
+	hctx := withWarnings(withName(ctx, e.name), &warns)
+	switch {
+	case e.mustRun:
+		hctx = context.WithoutCancel(hctx)
+		if e.mustRunTimeout > 0 {
+			var cancel context.CancelFunc
+			hctx, cancel = withTimeout(hctx, e.mustRunTimeout, hookLimit(e, "must-run timeout"))
+			defer cancel()
+		}
+	case rn.cfg.hookTimeout > 0:
+		var cancel context.CancelFunc
+		hctx, cancel = withTimeout(hctx, rn.cfg.hookTimeout, hookLimit(e, "timeout"))
+		defer cancel()
+	}
+	if budget > 0 && !e.mustRun {
+		var cancel context.CancelFunc
+		hctx, cancel = withTimeout(hctx, budget, hookLimit(e, "budget"))
+		defer cancel()
+		res.Budget = budget
+	}
+
+	for _, o := range rn.observers {
+		o.hookStarted(ctx, e.name)
+	}
+	stopWatchdog := rn.cfg.watch(ctx, e.name)
+
+	start := time.Now()
+	rn.cfg.profile(hctx, e, func(ctx context.Context) {
+		traceHook(ctx, e, func(ctx context.Context) {
+			res.Panic, res.Err = call(ctx, e.fn, rn.middleware)
+		})
+	})
+	res.Duration = time.Since(start)
+	res.Err = timeoutCause(hctx, res.Err)
+	res.Warnings = warns.list()
+	if pe, ok := res.Err.(*PanicError); ok {
+		pe.Site = e.site
+	}
+	stopWatchdog()
+	res.Completed = ctx.Err() == nil
+
+	if rn.cfg.errorFilter != nil && res.Err != nil && res.Panic == nil {
+		res.Err = rn.cfg.errorFilter(res.Err)
+	}
+
+	for _, o := range rn.observers {
+		o.hookFinished(ctx, &res)
+	}
+
+	if res.Err != nil && rn.cancel != nil && !e.bestEffort && !isWarning(res.Err) {
+		rn.cancel(res.Err)
+	}
+
+	return res
+}
+
+// skip records the given hooks as not started in an abandoned run and
+// returns their errors.
+func (rn *runner) skip(hooks []entry) []error {
+	var hookErrs []error
+	for _, e := range hooks {
+		res := newResult(e)
+		res.Err = ErrNotStarted
+		if err := rn.failure(&res); err != nil {
+			hookErrs = append(hookErrs, err)
+		}
+		rn.report.Hooks = append(rn.report.Hooks, res)
+	}
+	return hookErrs
+}
+
+// newResult returns the initial result for a hook that has not been started.
+func newResult(e entry) HookResult {
+	return HookResult{
+		id:         e.id,
+		Name:       e.name,
+		Index:      e.index,
+		Site:       e.site,
+		BestEffort: e.bestEffort,
+		Priority:   e.priority,
+		Skipped:    true,
+	}
+}
+
+// call invokes fn wrapped in the given middleware, converting a panic into a
+// *PanicError. The recovered value is returned along with the error. A panic
+// with a *PanicError, for example one raised by a nested registry, is not
+// wrapped a second time, so that its original value and stack are kept.
+func call(ctx context.Context, fn HookFunc, mw []Middleware) (p any, err error) {
+	defer func() {
+		if p = recover(); p != nil {
+			if pe, ok := p.(*PanicError); ok {
+				p, err = pe.Value, pe
+				return
+			}
+			err = newPanicError(p)
+		}
+	}()
+
+	return nil, wrap(fn, mw)(ctx)
 }
 
 // Len returns the number of registered hook functions.
 func (r *Registry) Len() int {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	return len(r.hooks)
+	return len(r.load())
 }
 
 // IsEmpty returns true if no hooks are registered.
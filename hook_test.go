@@ -0,0 +1,138 @@
+package hook_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/gulitsky/hook"
+)
+
+func BenchmarkRunParallel(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("hooks=%d", n), func(b *testing.B) {
+			r := hook.New()
+			for range n {
+				r.Add(func(context.Context) error { return nil })
+			}
+			ctx := context.Background()
+
+			b.ReportAllocs()
+			for b.Loop() {
+				if err := r.Run(ctx); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkRunParallelCancelable(b *testing.B) {
+	r := hook.New()
+	for range 100 {
+		r.Add(func(context.Context) error { return nil })
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := r.Run(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// recorder records the order in which hooks return.
+type recorder struct {
+	mu    sync.Mutex
+	order []string
+}
+
+// hook returns a HookFunc that records name once it returns.
+func (rec *recorder) hook(name string) hook.HookFunc {
+	return func(context.Context) error {
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		rec.order = append(rec.order, name)
+		return nil
+	}
+}
+
+func TestAfter(t *testing.T) {
+	type reg struct {
+		name  string
+		after []string
+	}
+	tests := []struct {
+		name  string
+		hooks []reg
+		// before lists pairs of hooks, the first of which must return
+		// before the second.
+		before [][2]string
+		want   int
+	}{
+		{
+			name: "chain",
+			hooks: []reg{
+				{"a", []string{"b"}},
+				{"b", []string{"c"}},
+				{"c", nil},
+				{"d", []string{"a"}},
+			},
+			before: [][2]string{{"c", "b"}, {"b", "a"}, {"a", "d"}},
+			want:   4,
+		},
+		{
+			name: "duplicate names",
+			hooks: []reg{
+				{"app", []string{"db"}},
+				{"db", nil},
+				{"db", nil},
+			},
+			before: [][2]string{{"db", "app"}},
+			want:   3,
+		},
+		{
+			name: "unknown names",
+			hooks: []reg{
+				{"a", []string{"missing"}},
+				{"b", []string{"a", "missing"}},
+			},
+			before: [][2]string{{"a", "b"}},
+			want:   2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := hook.New()
+			var rec recorder
+			for _, h := range tt.hooks {
+				r.Register(rec.hook(h.name), hook.WithName(h.name), hook.After(h.after...))
+			}
+
+			report, err := r.RunReport(context.Background())
+			if err != nil {
+				t.Fatalf("RunReport: %v", err)
+			}
+			if len(report.Hooks) != tt.want || len(rec.order) != tt.want {
+				t.Fatalf("%d results and %d calls, want %d", len(report.Hooks), len(rec.order), tt.want)
+			}
+			for _, pair := range tt.before {
+				// Every hook named pair[0] must have returned before the
+				// first hook named pair[1].
+				last := -1
+				for i, name := range rec.order {
+					if name == pair[0] {
+						last = i
+					}
+				}
+				if first := slices.Index(rec.order, pair[1]); last > first {
+					t.Errorf("order %v: %s returned after %s", rec.order, pair[0], pair[1])
+				}
+			}
+		})
+	}
+}
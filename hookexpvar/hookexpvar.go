@@ -0,0 +1,38 @@
+// Package hookexpvar publishes the state of a hook.Registry as expvar
+// variables, so that dashboards built on expvar pick up shutdown health
+// without further code.
+package hookexpvar
+
+import (
+	"expvar"
+
+	"github.com/gulitsky/hook"
+)
+
+// Publish publishes the state of r as the following expvar variables, each
+// name prefixed with prefix and a dot:
+//
+//	hooks                       number of registered hooks
+//	last_run_duration_seconds   duration of the last run
+//	last_run_errors             number of failed hooks in the last run
+//
+// The values are computed whenever the variables are read; both last-run
+// variables are 0 until r has run. Like expvar.Publish, Publish panics if
+// any of the names is already in use, so it must be called once per prefix.
+func Publish(prefix string, r *hook.Registry) {
+	expvar.Publish(prefix+".hooks", expvar.Func(func() any {
+		return r.Len()
+	}))
+	expvar.Publish(prefix+".last_run_duration_seconds", expvar.Func(func() any {
+		if report := r.LastReport(); report != nil {
+			return report.Duration.Seconds()
+		}
+		return 0.0
+	}))
+	expvar.Publish(prefix+".last_run_errors", expvar.Func(func() any {
+		if report := r.LastReport(); report != nil {
+			return len(report.Failed())
+		}
+		return 0
+	}))
+}
@@ -0,0 +1,140 @@
+// Package hookflush provides hooks for flush-style cleanup, such as syncing
+// loggers, flushing buffered writers and shutting down telemetry exporters,
+// which have to happen last so that nothing logged or recorded during
+// shutdown is lost.
+//
+// The adapters accept small interfaces instead of concrete types, so that
+// they work with *zap.Logger, *bufio.Writer, OpenTelemetry exporters and
+// providers and similar types without depending on their packages:
+//
+//	r.Register(hookflush.Sync(logger, hookflush.IgnoreBenign()),
+//		hook.WithPriority(hookflush.DefaultPriority))
+package hookflush
+
+import (
+	"context"
+	"errors"
+	"syscall"
+
+	"github.com/gulitsky/hook"
+)
+
+// DefaultPriority is a priority suitable for flush hooks. It is lower than
+// the default priorities of the other adapters of this module, so that
+// flushing happens after everything else has shut down.
+const DefaultPriority = -1000
+
+// Syncer is implemented by types that commit buffered data to storage, such
+// as *zap.Logger and *os.File.
+type Syncer interface {
+	Sync() error
+}
+
+// Flusher is implemented by types that write out buffered data, such as
+// *bufio.Writer.
+type Flusher interface {
+	Flush() error
+}
+
+// Shutdowner is implemented by types that flush and release their resources
+// with a context, such as OpenTelemetry exporters and providers.
+type Shutdowner interface {
+	Shutdown(context.Context) error
+}
+
+// Option configures a flush hook.
+type Option func(*config)
+
+type config struct {
+	ignore []func(error) bool
+	warn   []func(error) bool
+}
+
+// IgnoreErrors makes the hook succeed if the error it would return matches
+// pred.
+func IgnoreErrors(pred func(error) bool) Option {
+	return func(c *config) {
+		c.ignore = append(c.ignore, pred)
+	}
+}
+
+// IgnoreBenign makes the hook succeed if the error it would return is
+// benign according to IsBenign.
+func IgnoreBenign() Option {
+	return IgnoreErrors(IsBenign)
+}
+
+// WarnErrors makes the hook succeed if the error it would return matches
+// pred, and report the error as a warning with hook.Warn instead.
+func WarnErrors(pred func(error) bool) Option {
+	return func(c *config) {
+		c.warn = append(c.warn, pred)
+	}
+}
+
+// WarnBenign makes the hook report an error that is benign according to
+// IsBenign as a warning instead of failing.
+func WarnBenign() Option {
+	return WarnErrors(IsBenign)
+}
+
+// IsBenign reports whether err is one of the errors that syncing returns
+// for files that cannot be synced, such as standard error attached to a
+// terminal or pipe. A logger writing to standard error reports such an error
+// on every Sync, even though nothing was lost.
+func IsBenign(err error) bool {
+	return errors.Is(err, syscall.EINVAL) ||
+		errors.Is(err, syscall.ENOTTY) ||
+		errors.Is(err, syscall.ENOTSUP) ||
+		errors.Is(err, syscall.EBADF)
+}
+
+// Sync returns a HookFunc that calls s.Sync. The context is ignored.
+func Sync(s Syncer, opts ...Option) hook.HookFunc {
+	return wrap(func(context.Context) error {
+		return s.Sync()
+	}, opts)
+}
+
+// Flush returns a HookFunc that calls f.Flush. The context is ignored.
+func Flush(f Flusher, opts ...Option) hook.HookFunc {
+	return wrap(func(context.Context) error {
+		return f.Flush()
+	}, opts)
+}
+
+// Shutdown returns a HookFunc that calls s.Shutdown with the context of the
+// hook.
+func Shutdown(s Shutdowner, opts ...Option) hook.HookFunc {
+	return wrap(s.Shutdown, opts)
+}
+
+// wrap applies the options to fn.
+func wrap(fn hook.HookFunc, opts []Option) hook.HookFunc {
+	var c config
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if len(c.ignore) == 0 && len(c.warn) == 0 {
+		return fn
+	}
+
+	return func(ctx context.Context) error {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		for _, ignore := range c.ignore {
+			if ignore(err) {
+				return nil
+			}
+		}
+		for _, warn := range c.warn {
+			if warn(err) {
+				hook.Warn(ctx, err)
+				return nil
+			}
+		}
+		return err
+	}
+}
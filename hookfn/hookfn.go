@@ -0,0 +1,100 @@
+// Package hookfn provides wrappers that compose hook functions, so that the
+// behavior of a single hook can be adjusted without affecting the other hooks
+// of a registry:
+//
+//	r.AddNamed("cache", hookfn.Timeout(
+//		hookfn.Retry(cache.Flush, 3, hook.ConstantBackoff(100*time.Millisecond)),
+//		2*time.Second,
+//	))
+package hookfn
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/gulitsky/hook"
+)
+
+// Timeout returns a HookFunc that calls fn with a context that expires after
+// d.
+func Timeout(fn hook.HookFunc, d time.Duration) hook.HookFunc {
+	return func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeoutCause(ctx, d, &hook.TimeoutError{Limit: "timeout", Timeout: d})
+		defer cancel()
+		return fn(ctx)
+	}
+}
+
+// Retry returns a HookFunc that calls fn until it succeeds or has been
+// called attempts times in total, waiting between attempts as determined by
+// backoff. A nil backoff retries immediately. Retries stop early once the
+// context is done, and only the error of the last attempt is returned. It
+// is fn wrapped in hook.Retry.
+func Retry(fn hook.HookFunc, attempts int, backoff hook.BackoffFunc) hook.HookFunc {
+	return hook.Retry(attempts, backoff)(fn)
+}
+
+// Recover returns a HookFunc that calls fn and converts a panic into a
+// *hook.PanicError. Registries recover panics on their own; Recover is useful
+// inside other wrappers, such as Parallel, that call fn on goroutines of
+// their own. A panic with a *hook.PanicError is returned as is.
+func Recover(fn hook.HookFunc) hook.HookFunc {
+	return func(ctx context.Context) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				if pe, ok := p.(*hook.PanicError); ok {
+					err = pe
+					return
+				}
+				err = &hook.PanicError{Value: p, Stack: debug.Stack()}
+			}
+		}()
+		return fn(ctx)
+	}
+}
+
+// Sequence returns a HookFunc that calls fns one after another and stops at
+// the first one that returns an error, which is returned.
+func Sequence(fns ...hook.HookFunc) hook.HookFunc {
+	return func(ctx context.Context) error {
+		for _, fn := range fns {
+			if err := fn(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Parallel returns a HookFunc that calls fns concurrently and waits for all
+// of them to return. The errors are joined with errors.Join in the order of
+// fns. Panics are recovered as with Recover.
+func Parallel(fns ...hook.HookFunc) hook.HookFunc {
+	return func(ctx context.Context) error {
+		errs := make([]error, len(fns))
+		var wg sync.WaitGroup
+		for i, fn := range fns {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = Recover(fn)(ctx)
+			}()
+		}
+		wg.Wait()
+		return errors.Join(errs...)
+	}
+}
+
+// If returns a HookFunc that calls fn only if pred returns true when the hook
+// is run. Otherwise, it returns nil.
+func If(pred func() bool, fn hook.HookFunc) hook.HookFunc {
+	return func(ctx context.Context) error {
+		if !pred() {
+			return nil
+		}
+		return fn(ctx)
+	}
+}
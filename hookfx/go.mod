@@ -0,0 +1,15 @@
+module github.com/gulitsky/hook/hookfx
+
+go 1.24.5
+
+require (
+	github.com/gulitsky/hook v1.1.0
+	go.uber.org/fx v1.24.0
+)
+
+require (
+	go.uber.org/dig v1.19.0 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	go.uber.org/zap v1.26.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+)
@@ -0,0 +1,58 @@
+// Package hookfx bridges hook registries and lifecycles with the lifecycle of
+// Uber's fx framework, so that hooks can be reused when migrating to or from
+// fx.
+//
+// In one direction, Register and Append attach a hook.Registry or a
+// hook.Lifecycle to an fx application:
+//
+//	fx.Invoke(func(lc fx.Lifecycle) {
+//		hookfx.Register(lc, hook.Default())
+//	})
+//
+// In the other, Lifecycle lets constructors written for fx append their hooks
+// to a hook.Lifecycle.
+package hookfx
+
+import (
+	"context"
+
+	"github.com/gulitsky/hook"
+	"go.uber.org/fx"
+)
+
+// Register appends a hook to lc that runs r with the given options when the
+// fx application stops.
+func Register(lc fx.Lifecycle, r *hook.Registry, opts ...hook.Option) {
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return r.Run(ctx, opts...)
+		},
+	})
+}
+
+// Append appends a hook to lc that starts l when the fx application starts
+// and stops it when the application stops.
+func Append(lc fx.Lifecycle, l *hook.Lifecycle) {
+	lc.Append(fx.Hook{
+		OnStart: l.Start,
+		OnStop:  l.Stop,
+	})
+}
+
+// Lifecycle returns an fx.Lifecycle that appends the hooks it is given to l.
+func Lifecycle(l *hook.Lifecycle) fx.Lifecycle {
+	return lifecycle{l: l}
+}
+
+// lifecycle implements fx.Lifecycle on top of a hook.Lifecycle.
+type lifecycle struct {
+	l *hook.Lifecycle
+}
+
+// Append implements fx.Lifecycle.
+func (lc lifecycle) Append(h fx.Hook) {
+	lc.l.Append(hook.LifecycleHook{
+		OnStart: h.OnStart,
+		OnStop:  h.OnStop,
+	})
+}
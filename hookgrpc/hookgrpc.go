@@ -0,0 +1,137 @@
+// Package hookgrpc registers graceful shutdown of gRPC servers with a hook
+// registry, and provides interceptors for call-scoped cleanup hooks.
+package hookgrpc
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/gulitsky/hook"
+	"google.golang.org/grpc"
+)
+
+// DefaultPriority is the priority servers are registered with by default. It
+// is higher than the default priority of 0, so that servers stop accepting
+// calls before the resources used to serve them are released.
+const DefaultPriority = 100
+
+// Server is the subset of *grpc.Server used by this package.
+type Server interface {
+	GracefulStop()
+	Stop()
+}
+
+// Option configures how a server is registered.
+type Option func(*config)
+
+type config struct {
+	name     string
+	priority int
+}
+
+// WithName sets the name of the hook. The default name is "grpc".
+func WithName(name string) Option {
+	return func(c *config) {
+		c.name = name
+	}
+}
+
+// WithPriority sets the priority of the hook. The default is
+// DefaultPriority.
+func WithPriority(prio int) Option {
+	return func(c *config) {
+		c.priority = prio
+	}
+}
+
+// Register registers a hook with r that stops srv. See Stop.
+func Register(r *hook.Registry, srv Server, opts ...Option) hook.Token {
+	c := config{name: "grpc", priority: DefaultPriority}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return r.Register(Stop(srv),
+		hook.WithName(c.name),
+		hook.WithPriority(c.priority))
+}
+
+// Stop returns a HookFunc that gracefully stops srv, waiting for pending
+// calls to complete. Since GracefulStop does not accept a context, it is
+// run in the background; if the context expires first, srv is stopped
+// forcibly with Stop, which cancels all pending calls, and the context's
+// error is returned.
+func Stop(srv Server) hook.HookFunc {
+	return func(ctx context.Context) error {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			srv.GracefulStop()
+		}()
+
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			srv.Stop()
+			<-done
+			return ctx.Err()
+		}
+	}
+}
+
+// UnaryServerInterceptor returns an interceptor that attaches a new
+// hook.Registry to the context of every unary call and runs it once the
+// handler has returned, even if it panicked. Handlers can thus defer
+// cleanup to the end of the call with hook.FromContext.
+//
+// The hooks run one at a time in reverse order of registration, with a
+// context that is not canceled when the call is. Their errors are logged to
+// logger, or to slog.Default if logger is nil.
+func UnaryServerInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		r := hook.New(hook.WithCapacity(2))
+		defer runScope(ctx, r, logger, info.FullMethod)
+		return handler(hook.WithContext(ctx, r), req)
+	}
+}
+
+// StreamServerInterceptor returns an interceptor that attaches a new
+// hook.Registry to the context of every streaming call and runs it once the
+// handler has returned, like UnaryServerInterceptor.
+func StreamServerInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		r := hook.New(hook.WithCapacity(2))
+		defer runScope(ctx, r, logger, info.FullMethod)
+		return handler(srv, &scopedStream{ServerStream: ss, ctx: hook.WithContext(ctx, r)})
+	}
+}
+
+// scopedStream is a grpc.ServerStream with a replaced context.
+type scopedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context of the stream, which carries the registry of
+// the call.
+func (s *scopedStream) Context() context.Context {
+	return s.ctx
+}
+
+// runScope runs the registry of a call and logs its error.
+func runScope(ctx context.Context, r *hook.Registry, logger *slog.Logger, method string) {
+	ctx = context.WithoutCancel(ctx)
+	if err := r.Run(ctx, hook.WithSequential()); err != nil {
+		logger.ErrorContext(ctx, "call cleanup failed",
+			slog.String("method", method),
+			slog.Any("error", err))
+	}
+}
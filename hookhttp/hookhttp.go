@@ -0,0 +1,109 @@
+// Package hookhttp registers graceful shutdown of HTTP servers with a hook
+// registry, and provides middleware for request-scoped cleanup hooks.
+package hookhttp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gulitsky/hook"
+)
+
+// DefaultPriority is the priority servers are registered with by default. It
+// is higher than the default priority of 0, so that servers stop accepting
+// requests before the resources used to serve them are released.
+const DefaultPriority = 100
+
+// Option configures how a server is registered.
+type Option func(*config)
+
+type config struct {
+	name       string
+	priority   int
+	forceClose bool
+}
+
+// WithName sets the name of the hook. The default name is "http".
+func WithName(name string) Option {
+	return func(c *config) {
+		c.name = name
+	}
+}
+
+// WithPriority sets the priority of the hook. The default is
+// DefaultPriority.
+func WithPriority(prio int) Option {
+	return func(c *config) {
+		c.priority = prio
+	}
+}
+
+// WithForceClose makes the hook close all remaining connections, including
+// active ones, if the context expires before the graceful shutdown has
+// completed.
+func WithForceClose() Option {
+	return func(c *config) {
+		c.forceClose = true
+	}
+}
+
+// Register registers a hook with r that gracefully shuts down srv: it stops
+// the listeners, closes idle connections and waits for active requests to
+// complete until the context of the run expires.
+func Register(r *hook.Registry, srv *http.Server, opts ...Option) hook.Token {
+	c := config{name: "http", priority: DefaultPriority}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return r.Register(Shutdown(srv, c.forceClose),
+		hook.WithName(c.name),
+		hook.WithPriority(c.priority))
+}
+
+// Shutdown returns a HookFunc that gracefully shuts down srv. If forceClose
+// is set and the context expires first, the remaining connections are closed
+// forcibly.
+func Shutdown(srv *http.Server, forceClose bool) hook.HookFunc {
+	return func(ctx context.Context) error {
+		err := srv.Shutdown(ctx)
+		if forceClose && ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+			err = errors.Join(err, srv.Close())
+		}
+		return err
+	}
+}
+
+// RequestScope returns middleware that attaches a new hook.Registry to the
+// context of every request and runs it once next has returned, even if it
+// panicked. Code serving the request can thus defer cleanup to the end of
+// the request with hook.FromContext:
+//
+//	hook.FromContext(r.Context()).Add(func(ctx context.Context) error {
+//		return tmp.Close()
+//	})
+//
+// The hooks run one at a time in reverse order of registration, with a
+// context that is not canceled when the client goes away. Their errors are
+// logged to logger, or to slog.Default if logger is nil.
+func RequestScope(next http.Handler, logger *slog.Logger) http.Handler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r := hook.New(hook.WithCapacity(2))
+		ctx := req.Context()
+		defer func() {
+			ctx := context.WithoutCancel(ctx)
+			if err := r.Run(ctx, hook.WithSequential()); err != nil {
+				logger.ErrorContext(ctx, "request cleanup failed",
+					slog.String("method", req.Method),
+					slog.String("path", req.URL.Path),
+					slog.Any("error", err))
+			}
+		}()
+		next.ServeHTTP(w, req.WithContext(hook.WithContext(ctx, r)))
+	})
+}
@@ -0,0 +1,95 @@
+// Package hookk8s sequences the graceful shutdown of processes running in
+// Kubernetes pods.
+//
+// When a pod is terminated, Kubernetes removes it from the endpoints of its
+// services at the same time as it signals the process. Until the removal has
+// propagated to every node and load balancer, new requests keep arriving, so
+// a process that stops its servers right away drops them. The process must
+// first report that it is no longer ready, keep serving for a drain delay,
+// and only then run its shutdown hooks. Run implements this sequence.
+package hookk8s
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gulitsky/hook"
+)
+
+// DefaultDrainDelay is a drain delay that is long enough for endpoint
+// changes to propagate in most clusters.
+const DefaultDrainDelay = 5 * time.Second
+
+// Readiness is an http.Handler for readiness probes. It responds with 200 OK
+// until SetNotReady is called and with 503 Service Unavailable afterwards.
+// The zero value is ready.
+type Readiness struct {
+	since atomic.Int64 // Unix nanoseconds of SetNotReady, or 0 if ready
+}
+
+// SetNotReady makes the readiness probe fail from now on. Only the first
+// call has an effect.
+func (rd *Readiness) SetNotReady() {
+	rd.since.CompareAndSwap(0, time.Now().UnixNano())
+}
+
+// Ready reports whether SetNotReady has not been called yet.
+func (rd *Readiness) Ready() bool {
+	return rd.since.Load() == 0
+}
+
+// ServeHTTP implements http.Handler.
+func (rd *Readiness) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if !rd.Ready() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("shutting down\n"))
+		return
+	}
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+// PreStop returns an http.Handler for an httpGet preStop hook of a
+// container. It makes the readiness probe fail and responds once delay has
+// passed, so that Kubernetes signals the process only after the pod has
+// been drained. A later call to Run does not wait again.
+func (rd *Readiness) PreStop(delay time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rd.SetNotReady()
+		rd.wait(req.Context(), delay)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// wait blocks until delay has passed since SetNotReady was called or ctx is
+// done.
+func (rd *Readiness) wait(ctx context.Context, delay time.Duration) {
+	d := time.Until(time.Unix(0, rd.since.Load()).Add(delay))
+	if d <= 0 {
+		return
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
+
+// Run makes the readiness probe fail, waits until delay has passed since
+// then and runs r. The delay counts against the deadline of ctx; if ctx
+// expires while waiting, r is run immediately. If rd is nil, only the delay
+// is observed.
+//
+// Run is meant to be called once the process has been asked to terminate,
+// for example after receiving SIGTERM.
+func Run(ctx context.Context, r *hook.Registry, rd *Readiness, delay time.Duration, opts ...hook.Option) error {
+	if rd == nil {
+		rd = new(Readiness)
+	}
+	rd.SetNotReady()
+	rd.wait(ctx, delay)
+	return r.Run(ctx, opts...)
+}
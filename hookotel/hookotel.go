@@ -0,0 +1,102 @@
+// Package hookotel provides OpenTelemetry tracing for hook registries.
+//
+// A Tracer creates a span for every run of a registry and a child span for
+// every hook executed by it:
+//
+//	t := hookotel.New()
+//	r.Use(t.Middleware())
+//	err := t.Run(ctx, r)
+package hookotel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gulitsky/hook"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies this package as the instrumentation scope.
+const instrumentationName = "github.com/gulitsky/hook/hookotel"
+
+// Option configures a Tracer.
+type Option func(*Tracer)
+
+// WithTracerProvider sets the TracerProvider used to create spans. By
+// default, the global TracerProvider is used.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(t *Tracer) {
+		t.provider = tp
+	}
+}
+
+// Tracer creates spans for registry runs and hook executions.
+type Tracer struct {
+	provider trace.TracerProvider
+	tracer   trace.Tracer
+}
+
+// New creates a new Tracer with the given options.
+func New(opts ...Option) *Tracer {
+	t := &Tracer{provider: otel.GetTracerProvider()}
+	for _, opt := range opts {
+		opt(t)
+	}
+	t.tracer = t.provider.Tracer(instrumentationName)
+	return t
+}
+
+// Run runs r within a "registry.Run" span. Hook spans created by the
+// middleware of t become children of this span.
+func (t *Tracer) Run(ctx context.Context, r *hook.Registry, opts ...hook.Option) error {
+	ctx, span := t.tracer.Start(ctx, "registry.Run")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("hook.count", r.Len()))
+
+	err := r.Run(ctx, opts...)
+	record(span, err)
+	return err
+}
+
+// Middleware returns a hook.Middleware that wraps every hook in a span named
+// after the hook. Errors and panics are recorded on the span.
+func (t *Tracer) Middleware() hook.Middleware {
+	return func(next hook.HookFunc) hook.HookFunc {
+		return func(ctx context.Context) (err error) {
+			name := hook.NameFromContext(ctx)
+			spanName := name
+			if spanName == "" {
+				spanName = "hook"
+			}
+
+			ctx, span := t.tracer.Start(ctx, spanName,
+				trace.WithAttributes(attribute.String("hook.name", name)))
+			defer span.End()
+
+			defer func() {
+				if p := recover(); p != nil {
+					record(span, fmt.Errorf("hook function panic: %v", p))
+					panic(p)
+				}
+			}()
+
+			err = next(ctx)
+			record(span, err)
+			return err
+		}
+	}
+}
+
+// record sets the status of span according to err.
+func record(span trace.Span, err error) {
+	if err == nil {
+		span.SetStatus(codes.Ok, "")
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
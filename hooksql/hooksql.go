@@ -0,0 +1,124 @@
+// Package hooksql registers closing of database/sql connection pools with a
+// hook registry.
+package hooksql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/gulitsky/hook"
+)
+
+// DefaultPriority is the priority databases are registered with by default.
+// It is lower than the default priority of 0 and the priority of servers
+// registered by hookhttp and hookgrpc, so that databases are closed only
+// after the servers using them have stopped.
+const DefaultPriority = -100
+
+// defaultDrainInterval is how often the pool is polled while draining if no
+// interval is given.
+const defaultDrainInterval = 100 * time.Millisecond
+
+// DB is the subset of *sql.DB used by this package. It is also implemented
+// by wrappers embedding *sql.DB, such as *sqlx.DB.
+type DB interface {
+	Close() error
+	Stats() sql.DBStats
+}
+
+// Option configures how a database is registered.
+type Option func(*config)
+
+type config struct {
+	name          string
+	priority      int
+	drain         bool
+	drainInterval time.Duration
+}
+
+// WithName sets the name of the hook. The default name is "sql".
+func WithName(name string) Option {
+	return func(c *config) {
+		c.name = name
+	}
+}
+
+// WithPriority sets the priority of the hook. The default is
+// DefaultPriority.
+func WithPriority(prio int) Option {
+	return func(c *config) {
+		c.priority = prio
+	}
+}
+
+// WithDrain makes the hook wait until no connections are in use, that is
+// until in-flight queries and transactions have completed, before closing
+// the database. The pool is polled every interval, or every 100ms if
+// interval is not positive. Waiting stops when the context expires.
+func WithDrain(interval time.Duration) Option {
+	return func(c *config) {
+		c.drain = true
+		c.drainInterval = interval
+	}
+}
+
+// Register registers a hook with r that closes db. See Close.
+func Register(r *hook.Registry, db DB, opts ...Option) hook.Token {
+	c := config{name: "sql", priority: DefaultPriority}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	fn := Close(db)
+	if c.drain {
+		fn = Drain(db, c.drainInterval)
+	}
+	return r.Register(fn,
+		hook.WithName(c.name),
+		hook.WithPriority(c.priority))
+}
+
+// Close returns a HookFunc that closes db. Since closing waits for queries
+// that are being processed and does not accept a context, it is run in the
+// background; if the context expires first, the context's error is returned
+// while closing continues.
+func Close(db DB) hook.HookFunc {
+	return func(ctx context.Context) error {
+		done := make(chan error, 1)
+		go func() {
+			done <- db.Close()
+		}()
+
+		select {
+		case err := <-done:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Drain returns a HookFunc that waits until no connections of db are in use,
+// polling every interval, and then closes it as Close does. If the context
+// expires while waiting, db is closed anyway.
+func Drain(db DB, interval time.Duration) hook.HookFunc {
+	if interval <= 0 {
+		interval = defaultDrainInterval
+	}
+	closeDB := Close(db)
+
+	return func(ctx context.Context) error {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for db.Stats().InUse > 0 {
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				return closeDB(ctx)
+			}
+		}
+		return closeDB(ctx)
+	}
+}
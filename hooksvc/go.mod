@@ -0,0 +1,8 @@
+module github.com/gulitsky/hook/hooksvc
+
+go 1.24.5
+
+require (
+	github.com/gulitsky/hook v1.1.0
+	golang.org/x/sys v0.35.0
+)
@@ -0,0 +1,8 @@
+// Package hooksvc runs a hook registry when a Windows service is asked to
+// stop, so that the same shutdown hooks serve Windows services and processes
+// stopped by Unix signals.
+//
+// ListenAndRun waits for the stop and shutdown control requests of the
+// Windows service control manager when the process runs as a Windows
+// service, and for signals otherwise.
+package hooksvc
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package hooksvc
+
+import (
+	"context"
+
+	"github.com/gulitsky/hook"
+)
+
+// ListenAndRun blocks until the process is asked to terminate or ctx is
+// done, then runs the registry of l and returns the result of Run. On this
+// platform it is equivalent to l.ListenAndRun and name is ignored.
+func ListenAndRun(ctx context.Context, name string, l *hook.Listener) error {
+	return l.ListenAndRun(ctx)
+}
@@ -0,0 +1,103 @@
+//go:build windows
+
+package hooksvc
+
+import (
+	"context"
+	"time"
+
+	"github.com/gulitsky/hook"
+	"golang.org/x/sys/windows/svc"
+)
+
+// ListenAndRun blocks until the process is asked to terminate or ctx is
+// done, then runs the registry of l and returns the result of Run.
+//
+// If the process runs as a Windows service, it registers with the service
+// control manager as the service name, reports it as running and waits for
+// a stop or shutdown control request. While the hooks run, the service is
+// reported as stopping, with l.Grace as the wait hint. Otherwise, it is
+// equivalent to l.ListenAndRun.
+func ListenAndRun(ctx context.Context, name string, l *hook.Listener) error {
+	isService, err := svc.IsWindowsService()
+	if err != nil {
+		return err
+	}
+	if !isService {
+		return l.ListenAndRun(ctx)
+	}
+
+	h := &handler{ctx: ctx, l: l}
+	if err := svc.Run(name, h); err != nil {
+		return err
+	}
+	return h.err
+}
+
+// handler implements svc.Handler.
+type handler struct {
+	ctx context.Context
+	l   *hook.Listener
+	err error
+}
+
+// Execute implements svc.Handler.
+func (h *handler) Execute(_ []string, reqs <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	const accepts = svc.AcceptStop | svc.AcceptShutdown
+	status <- svc.Status{State: svc.Running, Accepts: accepts}
+
+wait:
+	for {
+		select {
+		case req := <-reqs:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				break wait
+			}
+		case <-h.ctx.Done():
+			break wait
+		}
+	}
+
+	stopping := svc.Status{
+		State:    svc.StopPending,
+		WaitHint: uint32(max(h.l.Grace, 0) / time.Millisecond),
+	}
+	status <- stopping
+
+	// Keep answering the control manager while the hooks run.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case req := <-reqs:
+				if req.Cmd == svc.Interrogate {
+					status <- stopping
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	r := h.l.Registry
+	if r == nil {
+		r = hook.Default()
+	}
+	ctx := context.WithoutCancel(h.ctx)
+	if h.l.Grace > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, h.l.Grace, &hook.TimeoutError{Limit: "grace period", Timeout: h.l.Grace})
+		defer cancel()
+	}
+	h.err = r.Run(ctx, h.l.Options...)
+	if h.err != nil {
+		// Report a service-specific exit code, so that the failure shows up
+		// in the event log and can trigger the recovery actions.
+		return true, 1
+	}
+	return false, 0
+}
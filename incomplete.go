@@ -0,0 +1,93 @@
+package hook
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// IncompleteError is reported when the context of a run is done before all
+// hooks have returned. It lists the hooks that were still running at that
+// moment and the hooks that were held back as a result. Hooks are identified by
+// their name, or by their index prefixed with '#' if they are unnamed.
+type IncompleteError struct {
+	// Cause is the cause of the context being done.
+	Cause error
+
+	// Running lists the hooks that were running when the context was done.
+	Running []string
+
+	// NotStarted lists the hooks that had not been started when the context
+	// was done and are not started at all because of it, in the order they
+	// were scheduled. This is the case with WithSequential, in fail-fast
+	// mode and if the context was done before the run started, except for
+	// hooks marked with MustRun. In the default concurrent mode, the
+	// remaining hooks are still started and are not listed.
+	NotStarted []string
+}
+
+// Error implements the error interface.
+func (e *IncompleteError) Error() string {
+	var b strings.Builder
+	b.WriteString("run interrupted")
+	if len(e.Running) > 0 {
+		fmt.Fprintf(&b, ", running: %s", strings.Join(e.Running, ", "))
+	}
+	if len(e.NotStarted) > 0 {
+		fmt.Fprintf(&b, ", not started: %s", strings.Join(e.NotStarted, ", "))
+	}
+	if e.Cause != nil {
+		fmt.Fprintf(&b, ": %v", e.Cause)
+	}
+	return b.String()
+}
+
+// Unwrap returns the cause of the context being done, so that errors.Is can
+// match context.DeadlineExceeded or context.Canceled.
+func (e *IncompleteError) Unwrap() error {
+	return e.Cause
+}
+
+// interrupt records the hooks that were running and not started when the
+// context of the run was done. The hooks of later groups are added to the
+// hooks not started. Only the hooks not started that are held back because
+// the context is done are recorded.
+func (rn *runner) interrupt(running, notStarted []entry) {
+	e := &IncompleteError{Cause: context.Cause(rn.ctx)}
+	for _, h := range running {
+		e.Running = append(e.Running, hookID(h))
+	}
+	for _, hooks := range [][]entry{notStarted, rn.later} {
+		for _, h := range hooks {
+			if !h.mustRun && rn.halted(rn.ctx) {
+				e.NotStarted = append(e.NotStarted, hookID(h))
+			}
+		}
+	}
+	rn.incomplete = e
+}
+
+// reverse returns a copy of the given hooks, which must be sorted by
+// descending priority, with every priority group reversed.
+func reverse(hooks []entry) []entry {
+	hooks = slices.Clone(hooks)
+	for rest := hooks; len(rest) > 0; {
+		n := 1
+		for n < len(rest) && rest[n].priority == rest[0].priority {
+			n++
+		}
+		slices.Reverse(rest[:n])
+		rest = rest[n:]
+	}
+	return hooks
+}
+
+// hookID identifies a hook by its name, or by its index if it is unnamed.
+func hookID(e entry) string {
+	if e.name != "" {
+		return e.name
+	}
+	return "#" + strconv.Itoa(e.index)
+}
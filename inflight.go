@@ -0,0 +1,74 @@
+package hook
+
+import (
+	"context"
+	"sync"
+)
+
+// Inflight counts the units of work in flight, such as requests being
+// served, so that shutdown can wait for them to complete. It replaces the
+// usual bridge between a sync.WaitGroup and a shutdown hook, with the
+// difference that waiting respects a context. The zero value is ready to
+// use.
+type Inflight struct {
+	mu   sync.Mutex
+	n    int
+	idle chan struct{}
+}
+
+// Inc records the start of a unit of work.
+func (f *Inflight) Inc() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.n == 0 {
+		f.idle = make(chan struct{})
+	}
+	f.n++
+}
+
+// Dec records the end of a unit of work. It panics if the count would
+// become negative.
+func (f *Inflight) Dec() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.n == 0 {
+		panic("hook: Inflight.Dec called more often than Inc")
+	}
+	f.n--
+	if f.n == 0 {
+		close(f.idle)
+	}
+}
+
+// Len returns the number of units of work in flight.
+func (f *Inflight) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.n
+}
+
+// Wait blocks until no work is in flight or ctx is done, in which case it
+// returns the context's error.
+func (f *Inflight) Wait(ctx context.Context) error {
+	f.mu.Lock()
+	if f.n == 0 {
+		f.mu.Unlock()
+		return nil
+	}
+	idle := f.idle
+	f.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Hook returns a HookFunc that waits until no work is in flight. Registered
+// with a lower priority than the hooks that stop accepting work, it delays
+// the release of resources until the work using them has completed.
+func (f *Inflight) Hook() HookFunc {
+	return f.Wait
+}
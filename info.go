@@ -0,0 +1,71 @@
+package hook
+
+import (
+	"slices"
+	"time"
+)
+
+// HookInfo describes a registered hook and the options it was registered
+// with. It is returned by Registry.Hooks.
+type HookInfo struct {
+	// Name is the name the hook was registered with, if any.
+	Name string
+
+	// Index is the position of the hook in the registry.
+	Index int
+
+	// Priority is the priority the hook was registered with.
+	Priority int
+
+	// Site is the file and line the hook was registered from, if
+	// WithCallSites was passed to New.
+	Site string
+
+	// Tags are the tags attached with WithTags.
+	Tags []string
+
+	// After lists the names of the hooks the hook waits for.
+	After []string
+
+	// BestEffort reports whether the hook was registered with BestEffort.
+	BestEffort bool
+
+	// MustRun reports whether the hook was registered with MustRun, and
+	// MustRunTimeout holds the timeout it was given.
+	MustRun        bool
+	MustRunTimeout time.Duration
+
+	// Once reports whether the hook was registered with AddOnce.
+	Once bool
+
+	// Delay is the delay set with WithDelay.
+	Delay time.Duration
+
+	// Serial is the key set with Serial.
+	Serial string
+}
+
+// Hooks returns a description of every registered hook, in registration
+// order. It tells what a library registered with a shared registry, such as
+// the default one.
+func (r *Registry) Hooks() []HookInfo {
+	hooks := r.load()
+	infos := make([]HookInfo, len(hooks))
+	for i, e := range hooks {
+		infos[i] = HookInfo{
+			Name:           e.name,
+			Index:          i,
+			Priority:       e.priority,
+			Site:           e.site,
+			Tags:           slices.Clone(e.tags),
+			After:          slices.Clone(e.after),
+			BestEffort:     e.bestEffort,
+			MustRun:        e.mustRun,
+			MustRunTimeout: e.mustRunTimeout,
+			Once:           e.once,
+			Delay:          e.delay,
+			Serial:         e.serial,
+		}
+	}
+	return infos
+}
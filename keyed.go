@@ -0,0 +1,134 @@
+package hook
+
+import (
+	"context"
+	"hash/maphash"
+	"sync"
+)
+
+// keyedShards is the number of shards of a KeyedRegistry. It is a power of
+// two, so that a shard can be selected by masking the hash of a key.
+const keyedShards = 32
+
+// KeyedRegistry manages a separate Registry for every key, such as the topic
+// of an event. Keys are spread over a number of shards with their own locks,
+// so that running or registering the hooks of one key does not contend with
+// the other keys. It is safe for concurrent use.
+type KeyedRegistry[K comparable] struct {
+	seed   maphash.Seed
+	shards [keyedShards]keyedShard[K]
+	opts   []Option
+}
+
+// keyedShard holds the registries of the keys hashed to it.
+type keyedShard[K comparable] struct {
+	mu   sync.RWMutex
+	regs map[K]*Registry
+}
+
+// NewKeyed creates a new KeyedRegistry. The given options are passed to New
+// for the registry of every key.
+func NewKeyed[K comparable](opts ...Option) *KeyedRegistry[K] {
+	return &KeyedRegistry[K]{seed: maphash.MakeSeed(), opts: opts}
+}
+
+// shard returns the shard responsible for key.
+func (k *KeyedRegistry[K]) shard(key K) *keyedShard[K] {
+	return &k.shards[maphash.Comparable(k.seed, key)&(keyedShards-1)]
+}
+
+// lookup returns the registry of key, or nil if no hooks were registered
+// for it.
+func (k *KeyedRegistry[K]) lookup(key K) *Registry {
+	s := k.shard(key)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.regs[key]
+}
+
+// Registry returns the registry of key, creating it if necessary. It can be
+// used to configure the hooks of a key beyond what the methods of
+// KeyedRegistry offer, for example with Use.
+func (k *KeyedRegistry[K]) Registry(key K) *Registry {
+	if r := k.lookup(key); r != nil {
+		return r
+	}
+
+	s := k.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r := s.regs[key]; r != nil {
+		return r
+	}
+	if s.regs == nil {
+		s.regs = make(map[K]*Registry)
+	}
+	r := New(k.opts...)
+	s.regs[key] = r
+	return r
+}
+
+// Add registers one or more hook functions for key. See Registry.Add.
+func (k *KeyedRegistry[K]) Add(key K, funcs ...HookFunc) Token {
+	return k.Registry(key).Add(funcs...)
+}
+
+// Register registers a hook function for key configured with the given
+// options. See Registry.Register.
+func (k *KeyedRegistry[K]) Register(key K, fn HookFunc, opts ...HookOption) Token {
+	return k.Registry(key).Register(fn, opts...)
+}
+
+// RemoveKey deregisters all hook functions of key. It reports whether any
+// hook was registered for it.
+func (k *KeyedRegistry[K]) RemoveKey(key K) bool {
+	s := k.shard(key)
+	s.mu.Lock()
+	r, ok := s.regs[key]
+	delete(s.regs, key)
+	s.mu.Unlock()
+	return ok && !r.IsEmpty()
+}
+
+// RunKey runs the hook functions of key like Registry.Run. It returns nil if
+// no hooks are registered for key.
+func (k *KeyedRegistry[K]) RunKey(ctx context.Context, key K, opts ...Option) error {
+	r := k.lookup(key)
+	if r == nil {
+		return nil
+	}
+	return r.Run(ctx, opts...)
+}
+
+// RunKeyReport is like RunKey, but also returns a Report. See
+// Registry.RunReport.
+func (k *KeyedRegistry[K]) RunKeyReport(ctx context.Context, key K, opts ...Option) (*Report, error) {
+	r := k.lookup(key)
+	if r == nil {
+		return nil, nil
+	}
+	return r.RunReport(ctx, opts...)
+}
+
+// Len returns the number of hook functions registered for key.
+func (k *KeyedRegistry[K]) Len(key K) int {
+	r := k.lookup(key)
+	if r == nil {
+		return 0
+	}
+	return r.Len()
+}
+
+// Keys returns the keys that have a registry, in no particular order.
+func (k *KeyedRegistry[K]) Keys() []K {
+	var keys []K
+	for i := range k.shards {
+		s := &k.shards[i]
+		s.mu.RLock()
+		for key := range s.regs {
+			keys = append(keys, key)
+		}
+		s.mu.RUnlock()
+	}
+	return keys
+}
@@ -0,0 +1,59 @@
+package hook
+
+import "sync"
+
+// latch is a one-time signal with callbacks, used for events such as a
+// lifecycle becoming ready. The zero value is not set.
+type latch struct {
+	mu        sync.Mutex
+	ch        chan struct{}
+	fired     bool
+	callbacks []func()
+}
+
+// done returns a channel that is closed once l is set.
+func (l *latch) done() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.ch == nil {
+		l.ch = make(chan struct{})
+		if l.fired {
+			close(l.ch)
+		}
+	}
+	return l.ch
+}
+
+// notify registers fn to be called once l is set, or calls it right away if
+// l is already set.
+func (l *latch) notify(fn func()) {
+	l.mu.Lock()
+	if !l.fired {
+		l.callbacks = append(l.callbacks, fn)
+		l.mu.Unlock()
+		return
+	}
+	l.mu.Unlock()
+	fn()
+}
+
+// set sets l and calls the registered callbacks. Only the first call has an
+// effect.
+func (l *latch) set() {
+	l.mu.Lock()
+	if l.fired {
+		l.mu.Unlock()
+		return
+	}
+	l.fired = true
+	if l.ch != nil {
+		close(l.ch)
+	}
+	callbacks := l.callbacks
+	l.callbacks = nil
+	l.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn()
+	}
+}
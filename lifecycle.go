@@ -0,0 +1,144 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrStarted is returned by Lifecycle.Start if the lifecycle has already been
+// started and not stopped since.
+var ErrStarted = errors.New("lifecycle already started")
+
+// LifecycleHook is a pair of hook functions run when a Lifecycle starts and
+// stops. Either function may be nil.
+type LifecycleHook struct {
+	// Name identifies the hook in errors.
+	Name string
+
+	// OnStart is run by Lifecycle.Start.
+	OnStart HookFunc
+
+	// OnStop is run by Lifecycle.Stop, or when a later start hook fails.
+	OnStop HookFunc
+}
+
+// Lifecycle manages paired start and stop hooks. Start hooks run one after
+// another in registration order, while stop hooks run in reverse order, so
+// that components are stopped in the opposite order of their start.
+// It is safe for concurrent use.
+type Lifecycle struct {
+	mu      sync.Mutex
+	hooks   []LifecycleHook
+	started []LifecycleHook
+	running bool
+	ready   latch
+}
+
+// NewLifecycle creates a new, empty Lifecycle.
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{}
+}
+
+// Append registers one or more lifecycle hooks. Hooks appended while the
+// lifecycle is running take effect on the next Start.
+func (l *Lifecycle) Append(hooks ...LifecycleHook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, hooks...)
+}
+
+// Start runs the start hooks in registration order. If a start hook fails or
+// panics, or ctx is done before all hooks have been started, Start runs the
+// stop hooks of all hooks started so far in reverse order and returns the
+// start error joined with any errors from the rollback. Since ctx may be the
+// reason startup failed, the rollback runs with a context that is detached
+// from it and limited to DefaultShutdownTimeout instead.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	l.mu.Lock()
+	if l.running {
+		l.mu.Unlock()
+		return ErrStarted
+	}
+	l.running = true
+	hooks := make([]LifecycleHook, len(l.hooks))
+	copy(hooks, l.hooks)
+	l.mu.Unlock()
+
+	started := make([]LifecycleHook, 0, len(hooks))
+	for _, h := range hooks {
+		err := ctx.Err()
+		if err == nil && h.OnStart != nil {
+			_, err = call(withName(ctx, h.Name), h.OnStart, nil)
+		}
+		if err != nil {
+			err = fmt.Errorf("start hook %q: %w", h.Name, err)
+			err = errors.Join(err, rollback(ctx, started))
+
+			l.mu.Lock()
+			l.running = false
+			l.mu.Unlock()
+			return err
+		}
+		started = append(started, h)
+	}
+
+	l.mu.Lock()
+	l.started = started
+	l.mu.Unlock()
+	l.ready.set()
+	return nil
+}
+
+// Ready returns a channel that is closed once Start has run all start hooks
+// successfully for the first time. Serving traffic, health checks or a
+// readiness notification to the service manager can key off it. The channel
+// stays closed after Stop.
+func (l *Lifecycle) Ready() <-chan struct{} {
+	return l.ready.done()
+}
+
+// OnReady registers fn to be called once Start has run all start hooks
+// successfully for the first time, or right away if that has already
+// happened.
+func (l *Lifecycle) OnReady(fn func()) {
+	l.ready.notify(fn)
+}
+
+// Stop runs the stop hooks of all started hooks in reverse order and returns
+// their errors joined. All stop hooks are run even if some of them fail.
+// Stop does nothing if the lifecycle is not running.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	started := l.started
+	l.started = nil
+	l.running = false
+	l.mu.Unlock()
+
+	return stop(ctx, started)
+}
+
+// stop runs the stop hooks of the given hooks in reverse order.
+func stop(ctx context.Context, hooks []LifecycleHook) error {
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		if h.OnStop == nil {
+			continue
+		}
+		if _, err := call(withName(ctx, h.Name), h.OnStop, nil); err != nil {
+			errs = append(errs, fmt.Errorf("stop hook %q: %w", h.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// rollback runs the stop hooks of the given started hooks after startup
+// failed, with a context that keeps the values of ctx but not its
+// cancellation, limited to DefaultShutdownTimeout.
+func rollback(ctx context.Context, started []LifecycleHook) error {
+	ctx, cancel := withTimeout(context.WithoutCancel(ctx), DefaultShutdownTimeout, "rollback timeout")
+	defer cancel()
+	return stop(ctx, started)
+}
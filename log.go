@@ -0,0 +1,85 @@
+package hook
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogLevels holds the levels at which a Registry logs hook events.
+type LogLevels struct {
+	// Start is the level for a hook being started.
+	Start slog.Level
+
+	// Done is the level for a hook returning successfully.
+	Done slog.Level
+
+	// Error is the level for a hook returning an error.
+	Error slog.Level
+
+	// Panic is the level for a hook panicking.
+	Panic slog.Level
+}
+
+// DefaultLogLevels are the levels used by WithLogger unless overridden with
+// WithLogLevels.
+var DefaultLogLevels = LogLevels{
+	Start: slog.LevelDebug,
+	Done:  slog.LevelDebug,
+	Error: slog.LevelError,
+	Panic: slog.LevelError,
+}
+
+// WithLogger logs the start and completion of every hook, including its
+// duration, error and panic value, to l.
+func WithLogger(l *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = l
+	}
+}
+
+// WithLogLevels sets the levels used for the messages of WithLogger.
+func WithLogLevels(levels LogLevels) Option {
+	return func(c *config) {
+		c.logLevels = &levels
+	}
+}
+
+// logObserver logs the start and completion of every hook.
+type logObserver struct {
+	logger *slog.Logger
+	levels LogLevels
+}
+
+// hookStarted logs that the hook with the given name is being started.
+func (o *logObserver) hookStarted(ctx context.Context, name string) {
+	o.logger.LogAttrs(ctx, o.levels.Start, "hook started", slog.String("hook", name))
+}
+
+// hookFinished logs the result of a hook.
+func (o *logObserver) hookFinished(ctx context.Context, res *HookResult) {
+	attrs := []slog.Attr{
+		slog.String("hook", res.Name),
+		slog.Duration("duration", res.Duration),
+	}
+
+	switch {
+	case res.Panic != nil:
+		attrs = append(attrs, slog.Any("panic", res.Panic))
+		o.logger.LogAttrs(ctx, o.levels.Panic, "hook panicked", attrs...)
+	case res.Err != nil:
+		attrs = append(attrs, slog.Any("error", res.Err))
+		o.logger.LogAttrs(ctx, o.levels.Error, "hook failed", attrs...)
+	default:
+		o.logger.LogAttrs(ctx, o.levels.Done, "hook finished", attrs...)
+	}
+}
+
+func (o *logObserver) runFinished(*Report) {}
+
+// levels returns the configured log levels.
+func (c *config) levels() LogLevels {
+	if c.logLevels != nil {
+		return *c.logLevels
+	}
+	return DefaultLogLevels
+}
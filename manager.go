@@ -0,0 +1,75 @@
+package hook
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// Manager holds a Registry for every named component of an application and
+// runs all of them from a single entry point. Every component owns its
+// registry, while RunAll coordinates their shutdown. It is safe for
+// concurrent use.
+//
+// Within RunAll, every registry is run like a single hook named after it,
+// so the order between registries is configured with the usual hook
+// options: WithPriority runs registries one after another, After makes a
+// registry wait for others, and the options passed to RunAll, such as
+// WithOrder or WithSequential, apply as well. By default, all registries
+// are run concurrently.
+type Manager struct {
+	mu    sync.Mutex
+	regs  map[string]*Registry
+	names []string
+	runs  *Registry
+	opts  []Option
+}
+
+// NewManager creates a new, empty Manager. The given options are passed to
+// New for the registry of every component.
+func NewManager(opts ...Option) *Manager {
+	return &Manager{
+		regs: make(map[string]*Registry),
+		runs: New(),
+		opts: opts,
+	}
+}
+
+// Registry returns the registry of the component with the given name,
+// creating it if necessary. The hook options determine how the registry is
+// run by RunAll relative to the other registries, for example
+// After("http") to shut a database down only once the HTTP server is. They
+// only take effect when the registry is created.
+func (m *Manager) Registry(name string, opts ...HookOption) *Registry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if r, ok := m.regs[name]; ok {
+		return r
+	}
+	r := New(m.opts...)
+	m.runs.Register(r.AsHook(), append(slices.Clip(opts), WithName(name))...)
+	m.regs[name] = r
+	m.names = append(m.names, name)
+	return r
+}
+
+// Names returns the names of the registries in the order they were created.
+func (m *Manager) Names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.names...)
+}
+
+// RunAll runs the registries of all components with ctx as described for
+// Manager, passing opts to the run that coordinates them. The errors of a
+// registry are wrapped in a *HookError named after it.
+func (m *Manager) RunAll(ctx context.Context, opts ...Option) error {
+	return m.runs.Run(ctx, opts...)
+}
+
+// RunAllReport is like RunAll, but also returns a Report that holds a result
+// for every registry.
+func (m *Manager) RunAllReport(ctx context.Context, opts ...Option) (*Report, error) {
+	return m.runs.RunReport(ctx, opts...)
+}
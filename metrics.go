@@ -0,0 +1,19 @@
+package hook
+
+// Metrics receives execution statistics for every hook that is run. It can be
+// implemented on top of any metrics system, such as Prometheus, to record
+// hook execution counts, durations, errors and panics. Implementations must
+// be safe for concurrent use, since hooks finish concurrently.
+type Metrics interface {
+	// ObserveHook is called once a hook has returned. The result holds the
+	// name, duration, error and panic value of the hook.
+	ObserveHook(res HookResult)
+}
+
+// WithMetrics reports the result of every hook to m as soon as the hook
+// returns.
+func WithMetrics(m Metrics) Option {
+	return func(c *config) {
+		c.metrics = m
+	}
+}
@@ -0,0 +1,34 @@
+package hook
+
+import "slices"
+
+// Middleware wraps a HookFunc to add behavior such as logging, metrics or
+// retries around its execution.
+type Middleware func(HookFunc) HookFunc
+
+// Use appends middleware to the Registry. Middleware is applied to every hook
+// at Run time, so it also affects hooks registered before Use was called.
+// The first middleware added is the outermost one. Use panics if r is
+// sealed.
+func (r *Registry) Use(mw ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sealed.Load() {
+		panic(ErrSealed)
+	}
+	var next []Middleware
+	if p := r.middleware.Load(); p != nil {
+		next = slices.Concat(*p, mw)
+	} else {
+		next = slices.Clone(mw)
+	}
+	r.middleware.Store(&next)
+}
+
+// wrap applies the middleware to fn, with mw[0] as the outermost layer.
+func wrap(fn HookFunc, mw []Middleware) HookFunc {
+	for i := len(mw) - 1; i >= 0; i-- {
+		fn = mw[i](fn)
+	}
+	return fn
+}
@@ -0,0 +1,87 @@
+package hook
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrDuplicateName is returned when a hook is registered under a name that
+// is already taken in strict mode.
+var ErrDuplicateName = errors.New("duplicate hook name")
+
+// WithStrictNames makes a Registry reject hooks registered under a name that
+// is already taken, which catches plugins that are accidentally registered
+// twice and would then close their resources twice. The registration methods
+// that cannot return an error, such as AddNamed, panic with an error wrapping
+// ErrDuplicateName instead. Hooks registered with the Replace option are
+// exempt. It only has an effect when passed to New.
+func WithStrictNames() Option {
+	return func(c *config) {
+		c.strictNames = true
+	}
+}
+
+// Replace makes a hook replace all hooks registered under the same name,
+// atomically, instead of being registered alongside them.
+func Replace() HookOption {
+	return func(e *entry) {
+		e.replace = true
+	}
+}
+
+// TryRegister is like Register, but returns an error wrapping
+// ErrDuplicateName if the name of the hook is already taken, regardless of
+// WithStrictNames, and ErrSealed if r is sealed.
+func (r *Registry) TryRegister(fn HookFunc, opts ...HookOption) (Token, error) {
+	e := entry{fn: fn}
+	for _, opt := range opts {
+		opt(&e)
+	}
+	return r.insert(true, []entry{e})
+}
+
+// replacedNames returns the names of the entries marked with Replace.
+func replacedNames(entries []entry) []string {
+	var names []string
+	for _, e := range entries {
+		if e.replace && e.name != "" {
+			names = append(names, e.name)
+		}
+	}
+	return names
+}
+
+// checkNames verifies that the names of the given entries are neither taken
+// by the registered hooks nor by each other.
+func checkNames(hooks, entries []entry) error {
+	taken := make(map[string]bool)
+	for _, e := range hooks {
+		if e.name != "" {
+			taken[e.name] = true
+		}
+	}
+	for _, e := range entries {
+		if e.name == "" {
+			continue
+		}
+		if taken[e.name] {
+			return fmt.Errorf("%w: %q", ErrDuplicateName, e.name)
+		}
+		taken[e.name] = true
+	}
+	return nil
+}
+
+// Only restricts a run to the hooks registered under one of the given names,
+// for example to trigger specific maintenance hooks from an admin endpoint.
+// Other hooks are neither run nor included in the report, and dependencies
+// on them are ignored. Combined with MatchTags, a hook is run if it matches
+// either option.
+func Only(names ...string) Option {
+	return func(c *config) {
+		c.filters = append(c.filters, func(e entry) bool {
+			return e.name != "" && slices.Contains(names, e.name)
+		})
+	}
+}
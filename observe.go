@@ -0,0 +1,97 @@
+package hook
+
+import "strconv"
+
+// ChangeKind is the kind of a Change to a Registry.
+type ChangeKind int
+
+const (
+	// HooksAdded means that hooks were registered.
+	HooksAdded ChangeKind = iota
+
+	// HooksRemoved means that hooks were removed, for example with Remove,
+	// Token.Remove or by a run consuming them.
+	HooksRemoved
+
+	// HooksCleared means that all hooks were removed with Clear.
+	HooksCleared
+)
+
+// String returns the name of the kind.
+func (k ChangeKind) String() string {
+	switch k {
+	case HooksAdded:
+		return "added"
+	case HooksRemoved:
+		return "removed"
+	case HooksCleared:
+		return "cleared"
+	default:
+		return "ChangeKind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// Change describes a modification of the hooks of a Registry.
+type Change struct {
+	Kind ChangeKind
+
+	// Names holds the names of the affected hooks, with an empty string for
+	// every unnamed hook, so its length is the number of affected hooks.
+	Names []string
+
+	// Len is the number of hooks registered after the change.
+	Len int
+}
+
+// observer is a function registered with Observe.
+type observer struct {
+	id uint64
+	fn func(Change)
+}
+
+// Observe registers fn to be called after every change to the hooks of r,
+// which lets frameworks mirror the state of the registry, for example into a
+// gauge of registered hooks. fn is called synchronously, in the order of the
+// changes, while r is locked: it may inspect r, but must not modify it. The
+// returned function stops the notifications.
+func (r *Registry) Observe(fn func(Change)) (stop func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastObserver++
+	id := r.lastObserver
+	r.observers = append(r.observers, observer{id: id, fn: fn})
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for i, o := range r.observers {
+			if o.id == id {
+				r.observers = append(r.observers[:i:i], r.observers[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// notify reports a change to the observers of r. The affected hooks are
+// those of hooks for which affected returns true, or all of them if affected
+// is nil. It must be called with r.mu held, after the change has been
+// stored.
+func (r *Registry) notify(kind ChangeKind, hooks []entry, affected func(entry) bool) {
+	if len(r.observers) == 0 {
+		return
+	}
+	c := Change{Kind: kind, Len: r.Len()}
+	for _, e := range hooks {
+		if affected == nil || affected(e) {
+			c.Names = append(c.Names, e.name)
+		}
+	}
+	if len(c.Names) == 0 && kind != HooksCleared {
+		return
+	}
+	for _, o := range r.observers {
+		o.fn(c)
+	}
+}
@@ -0,0 +1,105 @@
+package hook
+
+import (
+	"context"
+	"time"
+)
+
+// Observer receives callbacks as a run progresses. It is a generic
+// instrumentation point for plugging a Registry into monitoring systems.
+// Implementations must be safe for concurrent use, since hooks are started
+// and finish concurrently.
+type Observer interface {
+	// HookStarted is called right before the hook with the given name is
+	// called.
+	HookStarted(name string)
+
+	// HookFinished is called once the hook with the given name has returned,
+	// with the time it took and its error, if any.
+	HookFinished(name string, d time.Duration, err error)
+
+	// RunFinished is called once all hooks of a run have returned or been
+	// skipped, with the report of the run.
+	RunFinished(report *Report)
+}
+
+// WithObserver reports the progress of every run to o. It can be passed
+// several times, to New as well as to Run, to register several observers,
+// which are called in the order they were passed.
+func WithObserver(o Observer) Option {
+	return func(c *config) {
+		c.observers = append(c.observers, o)
+	}
+}
+
+// hookObserver is the form in which a run reports its progress internally.
+// Unlike Observer, it receives the context of the run and the complete
+// result of every hook. The Observers passed with WithObserver as well as
+// the logger, slow hook callback, error handler and metrics of a registry
+// are all hookObservers, so that hooks are instrumented in a single place.
+type hookObserver interface {
+	hookStarted(ctx context.Context, name string)
+	hookFinished(ctx context.Context, res *HookResult)
+	runFinished(report *Report)
+}
+
+// hookObservers returns the observers of a run, in the order they are
+// called.
+func (c *config) hookObservers() []hookObserver {
+	var obs []hookObserver
+	if c.logger != nil {
+		obs = append(obs, &logObserver{logger: c.logger, levels: c.levels()})
+	}
+	if c.slowHook != nil {
+		obs = append(obs, hookFinishedFunc(func(_ context.Context, res *HookResult) {
+			if res.Duration > c.slowThreshold {
+				c.slowHook(res.Name, res.Duration)
+			}
+		}))
+	}
+	if c.errorHandler != nil {
+		obs = append(obs, hookFinishedFunc(func(_ context.Context, res *HookResult) {
+			if res.Err != nil {
+				c.errorHandler(res.Name, res.Err)
+			}
+		}))
+	}
+	if c.metrics != nil {
+		obs = append(obs, hookFinishedFunc(func(_ context.Context, res *HookResult) {
+			c.metrics.ObserveHook(*res)
+		}))
+	}
+	for _, o := range c.observers {
+		obs = append(obs, publicObserver{o})
+	}
+	return obs
+}
+
+// publicObserver adapts an Observer to a hookObserver.
+type publicObserver struct {
+	Observer
+}
+
+func (o publicObserver) hookStarted(_ context.Context, name string) {
+	o.HookStarted(name)
+}
+
+func (o publicObserver) hookFinished(_ context.Context, res *HookResult) {
+	o.HookFinished(res.Name, res.Duration, res.Err)
+}
+
+func (o publicObserver) runFinished(report *Report) {
+	o.RunFinished(report)
+}
+
+// hookFinishedFunc is a hookObserver that is only interested in hooks
+// having returned.
+type hookFinishedFunc func(ctx context.Context, res *HookResult)
+
+func (hookFinishedFunc) hookStarted(context.Context, string) {}
+
+func (f hookFinishedFunc) hookFinished(ctx context.Context, res *HookResult) {
+	f(ctx, res)
+}
+
+func (hookFinishedFunc) runFinished(*Report) {}
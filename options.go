@@ -0,0 +1,274 @@
+package hook
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Option configures how a Registry runs its hooks. Options can be passed to
+// New, where they become the defaults of the registry, and to Run, where
+// they apply to a single run and take precedence.
+type Option func(*config)
+
+// config holds the settings applied to a single run of a Registry.
+type config struct {
+	capacity       int
+	history        int
+	callSites      bool
+	strictNames    bool
+	hookTimeout    time.Duration
+	runTimeout     time.Duration
+	order          Order
+	maxConcurrency int
+	stagger        time.Duration
+	limiter        Limiter
+	split          DeadlineSplit
+	stopWhenDone   bool
+	failFast       bool
+	cancelOnError  bool
+	consume        bool
+	coalesce       bool
+	retryAttempts  int
+	retryBackoff   BackoffFunc
+	graceful       time.Duration
+	forced         time.Duration
+	metrics        Metrics
+	observers      []Observer
+	profileLabels  bool
+	registryName   string
+	errorHandler   func(name string, err error)
+	errorFilter    func(error) error
+	panicPolicy    PanicPolicy
+	logger         *slog.Logger
+	logLevels      *LogLevels
+
+	watchdogThreshold time.Duration
+	watchdog          WatchdogFunc
+	slowThreshold     time.Duration
+	slowHook          func(name string, elapsed time.Duration)
+
+	// requireDeadline rejects runs whose context has no deadline.
+	requireDeadline bool
+
+	// ids, if set, restricts the run to the hooks with these IDs.
+	ids map[uint64]bool
+
+	// filters select the hooks to run; a hook is run if it matches any of
+	// them, or if there are none.
+	filters []func(entry) bool
+}
+
+// newConfig returns a config with all of the given options applied.
+func newConfig(opts []Option) config {
+	var c config
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// selects reports whether the hook e is part of the run.
+func (c *config) selects(e entry) bool {
+	if c.ids != nil && !c.ids[e.id] {
+		return false
+	}
+	if len(c.filters) == 0 {
+		return true
+	}
+	for _, f := range c.filters {
+		if f(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// consumes reports whether a run with this configuration removes the hook e
+// from the Registry.
+func (c *config) consumes(e entry) bool {
+	return (c.consume || e.once) && c.selects(e)
+}
+
+// WithSequential runs the hooks one at a time, in order, and stops starting
+// further hooks once the context is done. Hooks that were not started are
+// marked as skipped in the report and listed in the *IncompleteError
+// included in the error returned by Run. By contrast, the default concurrent
+// mode starts every hook, even with a context that is already done.
+func WithSequential() Option {
+	return func(c *config) {
+		c.maxConcurrency = 1
+		c.stopWhenDone = true
+	}
+}
+
+// WithCancelOnError cancels the context passed to the hooks as soon as the
+// first hook returns an error or panics, so that cooperative hooks stop
+// early, with the error as the cause. Unlike RunFailFast, the remaining
+// hooks are still started, with the canceled context, and the run waits for
+// every hook and returns all of their errors.
+func WithCancelOnError() Option {
+	return func(c *config) {
+		c.cancelOnError = true
+	}
+}
+
+// WithErrorHandler calls fn as soon as a hook returns an error or panics,
+// with the name of the hook and its error, in addition to the error being
+// included in the result of Run. This surfaces failures of long runs in real
+// time. fn may be called concurrently.
+func WithErrorHandler(fn func(name string, err error)) Option {
+	return func(c *config) {
+		c.errorHandler = fn
+	}
+}
+
+// WithCapacity sets the number of hooks a Registry pre-allocates space for.
+// It only has an effect when passed to New.
+func WithCapacity(n int) Option {
+	return func(c *config) {
+		c.capacity = n
+	}
+}
+
+// WithDefaultTimeout limits the time every single hook may take by giving it
+// a context that expires after d. The deadline of the context passed to Run
+// still applies. A value of d <= 0 means no per-hook limit.
+func WithDefaultTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.hookTimeout = d
+	}
+}
+
+// WithRunTimeout limits the time a whole run may take by running the hooks
+// with a context that expires after d. It is useful for a child registry,
+// whose run is started by its parent. A value of d <= 0 means no limit.
+func WithRunTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.runTimeout = d
+	}
+}
+
+// WithMaxConcurrency limits the number of hooks executed in parallel to n.
+// Hooks beyond the limit are started only as running ones finish, so at most
+// n goroutines are spawned at a time. A value of n <= 0 means no limit.
+func WithMaxConcurrency(n int) Option {
+	return func(c *config) {
+		c.maxConcurrency = n
+	}
+}
+
+// WithStagger spaces out the starts of the hooks of a priority group by at
+// least d, instead of starting them all at once, so that hooks contacting
+// the same service do not overload it. With WithSequential, hooks still
+// waiting to be started are skipped once the context is done. A value of
+// d <= 0 starts hooks without delay.
+func WithStagger(d time.Duration) Option {
+	return func(c *config) {
+		c.stagger = d
+	}
+}
+
+// Limiter limits the rate at which hooks are called. It is implemented by
+// *rate.Limiter from golang.org/x/time/rate.
+type Limiter interface {
+	// Wait blocks until the next hook may be called, or returns an error if
+	// ctx is done first.
+	Wait(ctx context.Context) error
+}
+
+// WithRateLimit makes every hook wait for l before it is called, for hooks
+// that call external APIs subject to a quota. Timeouts of the hook only
+// start once the wait is over. If the wait fails, for example because the
+// context of the run is done, the hook is not called and reports the error
+// of the wait. Hooks marked with MustRun wait regardless of the context.
+func WithRateLimit(l Limiter) Option {
+	return func(c *config) {
+		c.limiter = l
+	}
+}
+
+// WithConsumeOnRun controls whether the hooks of a run are removed from the
+// Registry. By default, hooks are retained, which suits event hooks that are
+// run repeatedly. With consume set to true, the hooks are removed atomically
+// as the run starts, which suits one-shot cleanup: a concurrent or later run
+// does not execute them again. Hooks excluded from the run, for example by
+// MatchTags, are retained either way.
+func WithConsumeOnRun(consume bool) Option {
+	return func(c *config) {
+		c.consume = consume
+	}
+}
+
+// HookOption configures a single hook at registration time.
+type HookOption func(*entry)
+
+// WithName sets the name of a hook registered with Register. See
+// Registry.AddNamed.
+func WithName(name string) HookOption {
+	return func(e *entry) {
+		e.name = name
+	}
+}
+
+// WithPriority sets the priority of a hook. See Registry.AddWithPriority.
+func WithPriority(prio int) HookOption {
+	return func(e *entry) {
+		e.priority = prio
+	}
+}
+
+// Critical marks a hook as critical, meaning that its failure makes the run
+// return an error. This is the default.
+func Critical() HookOption {
+	return func(e *entry) {
+		e.bestEffort = false
+	}
+}
+
+// BestEffort marks a hook as best-effort, meaning that its failure does not
+// make the run return an error. Instead, the error is recorded in the
+// Warnings of the report. Best-effort failures do not stop fail-fast runs
+// either. This suits hooks such as flushing optional telemetry.
+func BestEffort() HookOption {
+	return func(e *entry) {
+		e.bestEffort = true
+	}
+}
+
+// MustRun marks a hook as essential cleanup, such as releasing a distributed
+// lock, that has to happen even if the context of the run is already done.
+// The hook runs with a context that is detached from the cancellation of the
+// run's context and expires after timeout instead, or never if timeout is
+// not positive, leaving the hook to bound its own work. It is started even
+// if the run's context was done before Run was called, or if other hooks
+// are skipped because a hook failed in fail-fast mode or because of
+// WithSequential.
+func MustRun(timeout time.Duration) HookOption {
+	return func(e *entry) {
+		e.mustRun = true
+		e.mustRunTimeout = timeout
+	}
+}
+
+// WithDelay makes a hook wait until d has passed since the run started
+// before it is called, for example to give load balancers time to stop
+// routing to a server before its listeners are closed. Timeouts of the hook
+// only start once the delay has passed. If the context of the run is done
+// during the delay, the hook is not called and reports the context's error.
+func WithDelay(d time.Duration) HookOption {
+	return func(e *entry) {
+		e.delay = d
+	}
+}
+
+// Serial makes a hook run one at a time with the other hooks of the same
+// priority that share key, in the order they are started, while hooks with
+// other keys or without a key still run concurrently. For example, all
+// consumers of a message queue can be stopped one by one while the rest of
+// the shutdown proceeds in parallel. An empty key has no effect.
+func Serial(key string) HookOption {
+	return func(e *entry) {
+		e.serial = key
+	}
+}
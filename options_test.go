@@ -0,0 +1,391 @@
+package hook_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gulitsky/hook"
+)
+
+func TestConsumeOnRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		consume bool
+		wantLen int
+	}{
+		{"keep", false, 2},
+		{"consume", true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := hook.New()
+			calls := 0
+			count := func(context.Context) error {
+				calls++
+				return nil
+			}
+			r.Add(count, count)
+
+			if err := r.Run(context.Background(), hook.WithConsumeOnRun(tt.consume), hook.WithSequential()); err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if calls != 2 {
+				t.Errorf("hooks called %d times, want 2", calls)
+			}
+			if got := r.Len(); got != tt.wantLen {
+				t.Errorf("Len after run = %d, want %d", got, tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestConsumeOnRunDependencyCycle(t *testing.T) {
+	tests := []struct {
+		name string
+		add  func(r *hook.Registry, fn hook.HookFunc, opts ...hook.HookOption)
+		opts []hook.Option
+	}{
+		{
+			name: "WithConsumeOnRun",
+			add: func(r *hook.Registry, fn hook.HookFunc, opts ...hook.HookOption) {
+				r.Register(fn, opts...)
+			},
+			opts: []hook.Option{hook.WithConsumeOnRun(true)},
+		},
+		{
+			name: "AddOnce",
+			add: func(r *hook.Registry, fn hook.HookFunc, opts ...hook.HookOption) {
+				r.AddOnce(fn, opts...)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := hook.New()
+			called := false
+			fn := func(context.Context) error {
+				called = true
+				return nil
+			}
+			tt.add(r, fn, hook.WithName("a"), hook.After("b"))
+			tt.add(r, fn, hook.WithName("b"), hook.After("a"))
+
+			err := r.Run(context.Background(), tt.opts...)
+			if !errors.Is(err, hook.ErrDependencyCycle) {
+				t.Fatalf("Run error = %v, want %v", err, hook.ErrDependencyCycle)
+			}
+			if called {
+				t.Error("hook called despite dependency cycle")
+			}
+			if got := r.Len(); got != 2 {
+				t.Errorf("Len after failed run = %d, want 2", got)
+			}
+		})
+	}
+}
+
+// results returns the results of a report by hook name.
+func results(report *hook.Report) map[string]hook.HookResult {
+	m := make(map[string]hook.HookResult, len(report.Hooks))
+	for _, res := range report.Hooks {
+		m[res.Name] = res
+	}
+	return m
+}
+
+func TestGracePeriod(t *testing.T) {
+	errNoDeadline := errors.New("no deadline")
+	block := make(chan struct{})
+	defer close(block)
+
+	type reg struct {
+		name     string
+		priority int
+		fn       hook.HookFunc
+	}
+	tests := []struct {
+		name  string
+		opts  []hook.Option
+		hooks []reg
+		// want maps the hooks to the error they are reported with.
+		want    map[string]error
+		skipped []string
+	}{
+		{
+			name: "stuck and not started",
+			opts: []hook.Option{
+				hook.WithGracePeriod(10*time.Millisecond, 10*time.Millisecond),
+				hook.WithMaxConcurrency(1),
+				hook.WithOrder(hook.FIFO),
+			},
+			hooks: []reg{
+				{"stuck", 0, func(context.Context) error {
+					<-block
+					return nil
+				}},
+				{"late", 0, func(context.Context) error { return nil }},
+			},
+			want:    map[string]error{"stuck": hook.ErrStuck, "late": hook.ErrNotStarted},
+			skipped: []string{"late"},
+		},
+		{
+			name: "forced context",
+			opts: []hook.Option{hook.WithGracePeriod(10*time.Millisecond, time.Second)},
+			hooks: []reg{
+				{"graceful", 1, func(ctx context.Context) error {
+					<-ctx.Done()
+					return nil
+				}},
+				{"forced", 0, func(ctx context.Context) error {
+					if _, ok := ctx.Deadline(); !ok {
+						return errNoDeadline
+					}
+					return ctx.Err()
+				}},
+			},
+			want: map[string]error{"graceful": nil, "forced": nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := hook.New()
+			for _, h := range tt.hooks {
+				r.Register(h.fn, hook.WithName(h.name), hook.WithPriority(h.priority))
+			}
+
+			report, err := r.RunReport(context.Background(), tt.opts...)
+			if report == nil {
+				t.Fatalf("RunReport returned no report, error %v", err)
+			}
+			got := results(report)
+			if len(got) != len(tt.want) {
+				t.Fatalf("report has %d hooks, want %d", len(got), len(tt.want))
+			}
+			for name, want := range tt.want {
+				res := got[name]
+				if !errors.Is(res.Err, want) || want == nil && res.Err != nil {
+					t.Errorf("hook %s: error %v, want %v", name, res.Err, want)
+				}
+				if want != nil && !errors.Is(err, want) {
+					t.Errorf("Run error %v, want %v", err, want)
+				}
+				if skipped := slices.Contains(tt.skipped, name); res.Skipped != skipped {
+					t.Errorf("hook %s: Skipped = %t, want %t", name, res.Skipped, skipped)
+				}
+			}
+		})
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	errHook := errors.New("hook failed")
+	tests := []struct {
+		name      string
+		opts      []hook.Option
+		wantCalls int32
+	}{
+		{"joined", []hook.Option{hook.WithCoalesce()}, 1},
+		{"not coalesced", nil, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := hook.New()
+			var calls atomic.Int32
+			started := make(chan struct{}, 2)
+			release := make(chan struct{})
+			r.Add(func(context.Context) error {
+				calls.Add(1)
+				started <- struct{}{}
+				<-release
+				return errHook
+			})
+
+			type result struct {
+				report *hook.Report
+				err    error
+			}
+			results := make(chan result, 2)
+			run := func() {
+				report, err := r.RunReport(context.Background(), tt.opts...)
+				results <- result{report, err}
+			}
+			go run()
+			<-started
+			go run()
+			if tt.wantCalls == 2 {
+				<-started
+			} else {
+				// Give the second run time to join the first.
+				time.Sleep(20 * time.Millisecond)
+			}
+			close(release)
+
+			first, second := <-results, <-results
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("hook called %d times, want %d", got, tt.wantCalls)
+			}
+			for _, res := range []result{first, second} {
+				if !errors.Is(res.err, errHook) {
+					t.Errorf("Run error %v, want %v", res.err, errHook)
+				}
+				if res.report == nil || len(res.report.Hooks) != 1 || !errors.Is(res.report.Hooks[0].Err, errHook) {
+					t.Errorf("report %+v, want one hook failing with %v", res.report, errHook)
+				}
+			}
+			if shared := first.report == second.report; shared != (tt.wantCalls == 1) {
+				t.Errorf("report shared = %t, want %t", shared, tt.wantCalls == 1)
+			}
+		})
+	}
+}
+
+func TestSerial(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []string
+		// wantMax is the maximum number of hooks running at once per key,
+		// and wantTotal overall.
+		wantMax   map[string]int
+		wantTotal int
+	}{
+		{"one key", []string{"q", "q", "q"}, map[string]int{"q": 1}, 1},
+		{"two keys", []string{"q", "q", "r", "r"}, map[string]int{"q": 1, "r": 1}, 2},
+		{"mixed", []string{"q", "q", "", ""}, map[string]int{"q": 1, "": 2}, 3},
+		{"no key", []string{"", "", ""}, map[string]int{"": 3}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu      sync.Mutex
+				running = make(map[string]int)
+				maxes   = make(map[string]int)
+				total   int
+				maxAll  int
+			)
+			r := hook.New()
+			for _, key := range tt.keys {
+				r.Register(func(context.Context) error {
+					mu.Lock()
+					running[key]++
+					total++
+					maxes[key] = max(maxes[key], running[key])
+					maxAll = max(maxAll, total)
+					mu.Unlock()
+
+					// Stay running until as many hooks as expected run at
+					// once, so that a missing serialization shows.
+					for deadline := time.Now().Add(50 * time.Millisecond); time.Now().Before(deadline); {
+						mu.Lock()
+						n := total
+						mu.Unlock()
+						if n >= tt.wantTotal {
+							break
+						}
+						time.Sleep(time.Millisecond)
+					}
+
+					mu.Lock()
+					running[key]--
+					total--
+					mu.Unlock()
+					return nil
+				}, hook.Serial(key))
+			}
+
+			report, err := r.RunReport(context.Background())
+			if err != nil {
+				t.Fatalf("RunReport: %v", err)
+			}
+			if len(report.Hooks) != len(tt.keys) {
+				t.Fatalf("report has %d hooks, want %d", len(report.Hooks), len(tt.keys))
+			}
+			for key, want := range tt.wantMax {
+				if maxes[key] != want {
+					t.Errorf("key %q: %d hooks running at once, want %d", key, maxes[key], want)
+				}
+			}
+			if maxAll != tt.wantTotal {
+				t.Errorf("%d hooks running at once, want %d", maxAll, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestStagger(t *testing.T) {
+	const stagger = 30 * time.Millisecond
+	tests := []struct {
+		name    string
+		opts    []hook.Option
+		timeout time.Duration
+		// wantStarted is the number of hooks started; the others must be
+		// reported as skipped and not started.
+		wantStarted int
+		wantErr     error
+	}{
+		{"concurrent", nil, 0, 3, nil},
+		{"sequential", []hook.Option{hook.WithSequential()}, 0, 3, nil},
+		{"sequential, context done", []hook.Option{hook.WithSequential()}, stagger + stagger/2, 2, context.DeadlineExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu     sync.Mutex
+				starts []time.Time
+			)
+			r := hook.New()
+			names := []string{"a", "b", "c"}
+			for _, name := range names {
+				r.AddNamed(name, func(context.Context) error {
+					mu.Lock()
+					defer mu.Unlock()
+					starts = append(starts, time.Now())
+					return nil
+				})
+			}
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			report, err := r.RunReport(ctx, append(tt.opts, hook.WithStagger(stagger), hook.WithOrder(hook.FIFO))...)
+			if !errors.Is(err, tt.wantErr) || tt.wantErr == nil && err != nil {
+				t.Fatalf("RunReport error %v, want %v", err, tt.wantErr)
+			}
+
+			if len(starts) != tt.wantStarted {
+				t.Fatalf("%d hooks started, want %d", len(starts), tt.wantStarted)
+			}
+			// The stagger is measured from when the goroutine of a hook is
+			// started, so allow for the time it takes to get to the hook.
+			for i := 1; i < len(starts); i++ {
+				if gap := starts[i].Sub(starts[i-1]); gap < stagger-time.Millisecond {
+					t.Errorf("hook %d started %v after the previous one, want at least %v", i, gap, stagger)
+				}
+			}
+
+			got := results(report)
+			var wantNotStarted []string
+			for i, name := range names {
+				if skipped := i >= tt.wantStarted; got[name].Skipped != skipped {
+					t.Errorf("hook %s: Skipped = %t, want %t", name, got[name].Skipped, skipped)
+				} else if skipped {
+					wantNotStarted = append(wantNotStarted, name)
+				}
+			}
+			var incomplete *hook.IncompleteError
+			if errors.As(err, &incomplete) != (wantNotStarted != nil) {
+				t.Fatalf("RunReport error %v, want an IncompleteError: %t", err, wantNotStarted != nil)
+			}
+			if incomplete != nil && !slices.Equal(incomplete.NotStarted, wantNotStarted) {
+				t.Errorf("NotStarted = %v, want %v", incomplete.NotStarted, wantNotStarted)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package hook
+
+import "strconv"
+
+// Order determines the order in which the hooks of a priority group are
+// started.
+type Order int
+
+const (
+	// LIFO starts hooks in reverse order of registration, which suits
+	// resource cleanup. This is the default.
+	LIFO Order = iota
+
+	// FIFO starts hooks in order of registration, which suits startup and
+	// observer-style event hooks.
+	FIFO
+)
+
+// String returns the name of the order.
+func (o Order) String() string {
+	switch o {
+	case LIFO:
+		return "LIFO"
+	case FIFO:
+		return "FIFO"
+	default:
+		return "Order(" + strconv.Itoa(int(o)) + ")"
+	}
+}
+
+// WithOrder sets the order in which hooks with the same priority are
+// started. The default is LIFO.
+func WithOrder(o Order) Option {
+	return func(c *config) {
+		c.order = o
+	}
+}
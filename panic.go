@@ -0,0 +1,92 @@
+package hook
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is the error reported for a hook function that panicked. It
+// can be retrieved from the error returned by Run with errors.As.
+type PanicError struct {
+	// Value is the value the hook panicked with, unmodified. A runtime
+	// error, such as a nil pointer dereference, is kept as the runtime.Error
+	// it was raised with.
+	Value any
+
+	// Stack is the stack trace of the goroutine at the time of the panic.
+	Stack []byte
+
+	// Site is the file and line the hook was registered from, if
+	// WithCallSites was passed to New.
+	Site string
+}
+
+// newPanicError returns a PanicError for the value p, capturing the stack of
+// the calling goroutine. It must be called from the deferred function that
+// recovered p.
+func newPanicError(p any) *PanicError {
+	return &PanicError{Value: p, Stack: debug.Stack()}
+}
+
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	if e.Site != "" {
+		return fmt.Sprintf("hook function panic: %v (registered at %s)", e.Value, e.Site)
+	}
+	return fmt.Sprintf("hook function panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error, so that errors.Is and
+// errors.As can match it.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Repanic panics with the original value of e, as if the panic had never
+// been recovered, so that recovery logic and crash reporters further up see
+// the same value as for any other panic. The stack trace of the original
+// panic is only available as e.Stack.
+func (e *PanicError) Repanic() {
+	panic(e.Value)
+}
+
+// PanicPolicy determines how a run deals with hooks that panic.
+type PanicPolicy int
+
+const (
+	// PanicCollect recovers panics and reports them as errors of type
+	// *PanicError, like any other hook failure. It is the default.
+	PanicCollect PanicPolicy = iota
+
+	// PanicRepanic lets every hook run to completion as with PanicCollect,
+	// and then panics with the value the first panicking hook panicked
+	// with, so that the panic crashes the program unless it is recovered
+	// further up.
+	PanicRepanic
+
+	// PanicAbort stops the run as soon as a hook panics: the context passed
+	// to the hooks is canceled, no further hooks are started and Run panics
+	// with the value of the panic without waiting for the hooks that are
+	// still running.
+	PanicAbort
+)
+
+// WithPanicPolicy sets how a run deals with hooks that panic. See
+// PanicPolicy.
+func WithPanicPolicy(p PanicPolicy) Option {
+	return func(c *config) {
+		c.panicPolicy = p
+	}
+}
+
+// firstPanic returns the result of the first hook in the report that
+// panicked, or nil if none did.
+func (r *Report) firstPanic() *HookResult {
+	for i := range r.Hooks {
+		if r.Hooks[i].Panic != nil {
+			return &r.Hooks[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrStuck is reported for a hook that was still running when a
+	// two-phase run was abandoned.
+	ErrStuck = errors.New("hook did not return before the forced deadline")
+
+	// ErrNotStarted is reported for a hook that had not been started when a
+	// two-phase run was abandoned.
+	ErrNotStarted = errors.New("hook was not started before the forced deadline")
+)
+
+// WithGracePeriod runs the hooks in two phases, mirroring how orchestrators
+// such as Kubernetes stop a process. During the graceful phase, which lasts
+// at most graceful, hooks run with a live context. Once it ends, either
+// because the time is up or because the context passed to Run is done, the
+// forced phase begins: hooks that have not been started yet are run with a
+// fresh context that expires after forced. When the forced phase ends as
+// well, the run is abandoned: Run returns without waiting for the hooks that
+// are still running, which are reported with ErrStuck, and hooks that were
+// never started are reported with ErrNotStarted.
+//
+// Abandoned hooks keep running in the background until they return.
+func WithGracePeriod(graceful, forced time.Duration) Option {
+	return func(c *config) {
+		c.graceful = graceful
+		c.forced = forced
+	}
+}
+
+// phaseContext returns the context for a hook about to be started. In
+// two-phase mode, it begins the forced phase once the graceful phase is over
+// and marks the run as abandoned once the forced phase is over as well.
+func (rn *runner) phaseContext(ctx context.Context) context.Context {
+	if rn.grace == nil {
+		return ctx
+	}
+	if rn.force == nil {
+		if rn.grace.Err() == nil {
+			return ctx
+		}
+		rn.force, rn.forceCancel = withTimeout(context.WithoutCancel(rn.parent), rn.cfg.forced, "forced phase")
+	}
+	if rn.force.Err() != nil {
+		rn.abandoned = true
+	}
+	return rn.force
+}
+
+// endForce releases the resources of the forced phase, if it was begun.
+func (rn *runner) endForce() {
+	if rn.forceCancel != nil {
+		rn.forceCancel()
+	}
+}
@@ -0,0 +1,33 @@
+package hook
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// WithProfileLabels runs every hook with the pprof labels hook, set to the
+// name of the hook or "#" followed by its index if it has none, and
+// registry, set to the given name if it is not empty. CPU and goroutine
+// profiles taken during a slow run then attribute the work to specific
+// hooks.
+func WithProfileLabels(registry string) Option {
+	return func(c *config) {
+		c.profileLabels = true
+		c.registryName = registry
+	}
+}
+
+// profile calls fn with ctx carrying the pprof labels of the hook e if
+// WithProfileLabels is in effect, and with ctx otherwise.
+func (c *config) profile(ctx context.Context, e entry, fn func(context.Context)) {
+	if !c.profileLabels {
+		fn(ctx)
+		return
+	}
+
+	labels := []string{"hook", hookID(e)}
+	if c.registryName != "" {
+		labels = append(labels, "registry", c.registryName)
+	}
+	pprof.Do(ctx, pprof.Labels(labels...), fn)
+}
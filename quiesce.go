@@ -0,0 +1,81 @@
+package hook
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// Quiescer is a gate that stops a component from accepting new work during
+// shutdown while the work in flight completes. Work is admitted with Enter
+// and finished with Leave; once Drain has been called, Enter refuses new
+// work and Wait blocks until the work in flight has finished. The zero value
+// is open and ready to use.
+type Quiescer struct {
+	mu       sync.Mutex
+	draining bool
+	inflight Inflight
+}
+
+// Enter admits a unit of work and reports whether it may proceed. It returns
+// false once the Quiescer is draining; otherwise, Leave must be called when
+// the work has finished.
+func (q *Quiescer) Enter() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.draining {
+		return false
+	}
+	q.inflight.Inc()
+	return true
+}
+
+// Leave marks a unit of work admitted by Enter as finished.
+func (q *Quiescer) Leave() {
+	q.inflight.Dec()
+}
+
+// Drain makes the Quiescer refuse new work. It cannot be undone.
+func (q *Quiescer) Drain() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.draining = true
+}
+
+// Draining reports whether Drain has been called.
+func (q *Quiescer) Draining() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.draining
+}
+
+// Wait blocks until no work admitted by Enter is in flight or ctx is done,
+// in which case it returns the context's error. Without a call to Drain,
+// new work may be admitted as soon as Wait returns.
+func (q *Quiescer) Wait(ctx context.Context) error {
+	return q.inflight.Wait(ctx)
+}
+
+// Hook returns a HookFunc that drains the Quiescer and waits for the work in
+// flight, which makes it a natural first step of a staged shutdown.
+func (q *Quiescer) Hook() HookFunc {
+	return func(ctx context.Context) error {
+		q.Drain()
+		return q.Wait(ctx)
+	}
+}
+
+// Middleware returns an http.Handler that admits every request to next with
+// Enter, and responds with 503 Service Unavailable and a Connection: close
+// header to requests that arrive while draining.
+func (q *Quiescer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !q.Enter() {
+			w.Header().Set("Connection", "close")
+			http.Error(w, "shutting down", http.StatusServiceUnavailable)
+			return
+		}
+		defer q.Leave()
+		next.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,113 @@
+package hook
+
+import (
+	"context"
+	"time"
+)
+
+// Report describes the outcome of a single run of a Registry.
+type Report struct {
+	// Start is the time the run started.
+	Start time.Time
+
+	// Duration is the total time the run took.
+	Duration time.Duration
+
+	// Hooks holds the result of every hook, in the order the hooks were
+	// started.
+	Hooks []HookResult
+
+	// Warnings holds the errors of failed best-effort hooks, errors
+	// downgraded to a *Warning and the warnings reported with Warn, each
+	// wrapped in a *HookError. They are not part of the error returned by
+	// the run.
+	Warnings []error
+
+	// Incomplete is set if the context of the run was done before all hooks
+	// had returned, and lists the hooks that were affected.
+	Incomplete *IncompleteError
+}
+
+// HookResult describes the outcome of a single hook within a run.
+type HookResult struct {
+	// Name is the name the hook was registered with, if any.
+	Name string
+
+	// Index is the position of the hook in the registry when the run
+	// started.
+	Index int
+
+	// Priority is the priority the hook was registered with.
+	Priority int
+
+	// Site is the file and line the hook was registered from, if
+	// WithCallSites was passed to New.
+	Site string
+
+	// BestEffort reports whether the hook was registered as best-effort, in
+	// which case its failure is a warning rather than an error.
+	BestEffort bool
+
+	// Budget is the time the hook was allowed to take as determined by
+	// WithDeadlineSplit, or 0 if it was not limited.
+	Budget time.Duration
+
+	// Duration is the time the hook took to return.
+	Duration time.Duration
+
+	// Err is the error returned by the hook, or the error describing its
+	// panic.
+	Err error
+
+	// Panic is the value the hook panicked with, or nil if it did not panic.
+	Panic any
+
+	// Warnings holds the warnings the hook reported with Warn.
+	Warnings []error
+
+	// Completed reports whether the hook returned before the context of the
+	// run was done.
+	Completed bool
+
+	// Skipped reports whether the hook was never started, for example
+	// because an earlier hook failed in fail-fast mode.
+	Skipped bool
+
+	// id identifies the hook in its Registry for RunFailed.
+	id uint64
+}
+
+// Failed returns the results of the hooks that returned an error or
+// panicked.
+func (r *Report) Failed() []HookResult {
+	var failed []HookResult
+	for _, h := range r.Hooks {
+		if h.Err != nil {
+			failed = append(failed, h)
+		}
+	}
+	return failed
+}
+
+// RunFailed runs the hooks of r again that failed in the run described by
+// report, such as flush hooks after a transient network error, without
+// repeating the cleanup that succeeded. The hooks are run like RunReport
+// with the given options. Failed hooks that have since been removed from r,
+// including those consumed by the run, are not run. RunFailed returns nil
+// and no error if no hook failed.
+func (r *Registry) RunFailed(ctx context.Context, report *Report, opts ...Option) (*Report, error) {
+	if report == nil {
+		return nil, nil
+	}
+	failed := make(map[uint64]bool)
+	for _, res := range report.Failed() {
+		failed[res.id] = true
+	}
+	if len(failed) == 0 {
+		return nil, nil
+	}
+
+	cfg := r.config(opts)
+	cfg.ids = failed
+	return r.run(ctx, cfg)
+}
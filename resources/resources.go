@@ -0,0 +1,154 @@
+// Package resources tracks the ownership of resources such as connections,
+// so that leaks can be found in long-lived processes.
+//
+// A Tracker registers the cleanup of every resource with a hook registry and
+// records where the resource was acquired. Owners release resources with
+// Handle.Release once they are done with them; resources that are still
+// tracked are reported by Leaks, and closed when the registry runs:
+//
+//	t := resources.New(hook.Default())
+//	conn, err := dial(ctx)
+//	...
+//	h := t.Track("upstream", conn)
+//	defer h.Release()
+package resources
+
+import (
+	"context"
+	"io"
+	"math"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gulitsky/hook"
+)
+
+// Leak describes a resource that was tracked but not released.
+type Leak struct {
+	// Name is the name the resource was tracked under.
+	Name string
+
+	// Site is the file and line Track was called from.
+	Site string
+
+	// Since is the time the resource was tracked.
+	Since time.Time
+}
+
+// Option configures a Tracker.
+type Option func(*Tracker)
+
+// WithLeakHandler makes the Tracker call fn with the resources that are
+// still tracked when the registry runs, before they are closed. This reports
+// at process exit the resources whose owners never released them. fn is not
+// called if there are none.
+func WithLeakHandler(fn func([]Leak)) Option {
+	return func(t *Tracker) {
+		t.onLeak = fn
+	}
+}
+
+// Tracker tracks resources and registers their cleanup with a registry. It
+// is safe for concurrent use.
+type Tracker struct {
+	r      *hook.Registry
+	onLeak func([]Leak)
+
+	mu   sync.Mutex
+	live map[*Handle]struct{}
+}
+
+// New creates a Tracker registering with r. If a leak handler is set, it is
+// registered with the highest priority, so that it runs before any resource
+// is closed. Since tracking registers and removes hooks, r must not be
+// sealed.
+func New(r *hook.Registry, opts ...Option) *Tracker {
+	t := &Tracker{r: r, live: make(map[*Handle]struct{})}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if t.onLeak != nil {
+		r.Register(func(context.Context) error {
+			if leaks := t.Leaks(); len(leaks) > 0 {
+				t.onLeak(leaks)
+			}
+			return nil
+		}, hook.WithName("resources.leaks"), hook.WithPriority(math.MaxInt))
+	}
+	return t
+}
+
+// Handle is a tracked resource.
+type Handle struct {
+	t     *Tracker
+	leak  Leak
+	c     io.Closer
+	token hook.Token
+	once  sync.Once
+	err   error
+}
+
+// Track starts tracking the resource c under the given name and registers a
+// hook that closes it, configured with the given options. The hook is
+// removed once the resource is released.
+func (t *Tracker) Track(name string, c io.Closer, opts ...hook.HookOption) *Handle {
+	h := &Handle{t: t, c: c, leak: Leak{Name: name, Since: time.Now()}}
+	if _, file, line, ok := runtime.Caller(1); ok {
+		h.leak.Site = file + ":" + strconv.Itoa(line)
+	}
+
+	t.mu.Lock()
+	t.live[h] = struct{}{}
+	t.mu.Unlock()
+
+	opts = append([]hook.HookOption{hook.WithName(name)}, opts...)
+	h.token = t.r.Register(func(context.Context) error {
+		return h.close()
+	}, opts...)
+	return h
+}
+
+// Release closes the resource and stops tracking it. Only the first call
+// closes the resource; later calls return the same error.
+func (h *Handle) Release() error {
+	err := h.close()
+	h.token.Remove()
+	return err
+}
+
+// close closes the resource once and stops tracking it.
+func (h *Handle) close() error {
+	h.once.Do(func() {
+		h.t.mu.Lock()
+		delete(h.t.live, h)
+		h.t.mu.Unlock()
+		h.err = h.c.Close()
+	})
+	return h.err
+}
+
+// Leaks returns the resources that are tracked and have not been released,
+// oldest first.
+func (t *Tracker) Leaks() []Leak {
+	t.mu.Lock()
+	leaks := make([]Leak, 0, len(t.live))
+	for h := range t.live {
+		leaks = append(leaks, h.leak)
+	}
+	t.mu.Unlock()
+
+	slices.SortFunc(leaks, func(a, b Leak) int {
+		return a.Since.Compare(b.Since)
+	})
+	return leaks
+}
+
+// Len returns the number of tracked resources.
+func (t *Tracker) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.live)
+}
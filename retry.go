@@ -0,0 +1,89 @@
+package hook
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// BackoffFunc returns how long to wait before the given retry attempt. The
+// attempt number starts at 1 for the first retry.
+type BackoffFunc func(attempt int) time.Duration
+
+// ConstantBackoff returns a BackoffFunc that always waits d.
+func ConstantBackoff(d time.Duration) BackoffFunc {
+	return func(int) time.Duration {
+		return d
+	}
+}
+
+// ExponentialBackoff returns a BackoffFunc that waits base before the first
+// retry and doubles the wait for every further retry, up to limit. A limit
+// of 0 means no upper bound.
+func ExponentialBackoff(base, limit time.Duration) BackoffFunc {
+	return func(attempt int) time.Duration {
+		d := base
+		for i := 1; i < attempt && d < math.MaxInt64/2; i++ {
+			if limit > 0 && d >= limit {
+				break
+			}
+			d *= 2
+		}
+		if limit > 0 && d > limit {
+			return limit
+		}
+		return d
+	}
+}
+
+// WithRetry makes every hook that returns an error be retried until it
+// succeeds or has been called attempts times in total, waiting between
+// attempts as determined by backoff. A nil backoff retries immediately.
+// Retries stop early once the context is done, and hooks that panic are not
+// retried. Only the error of the last attempt is reported.
+func WithRetry(attempts int, backoff BackoffFunc) Option {
+	return func(c *config) {
+		c.retryAttempts = attempts
+		c.retryBackoff = backoff
+	}
+}
+
+// Retry returns a Middleware that retries the hooks it wraps as described
+// for WithRetry. It applies the policy to selected hooks instead of every
+// hook of a run.
+func Retry(attempts int, backoff BackoffFunc) Middleware {
+	return func(fn HookFunc) HookFunc {
+		return func(ctx context.Context) error {
+			err := fn(ctx)
+			for attempt := 1; err != nil && attempt < attempts; attempt++ {
+				var d time.Duration
+				if backoff != nil {
+					d = backoff(attempt)
+				}
+				if !sleep(ctx, d) {
+					break
+				}
+				err = fn(ctx)
+			}
+			return err
+		}
+	}
+}
+
+// sleep waits for d or until ctx is done, whichever happens first. It reports
+// whether the full duration elapsed.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
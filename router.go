@@ -0,0 +1,140 @@
+package hook
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+// RouteOption configures how a SignalRouter handles a signal.
+type RouteOption func(*route)
+
+// WithRouteTimeout limits the time the run triggered by a signal may take.
+func WithRouteTimeout(d time.Duration) RouteOption {
+	return func(rt *route) {
+		rt.timeout = d
+	}
+}
+
+// WithRouteOptions sets the options the run triggered by a signal is made
+// with. For example, MatchTags restricts it to a subset of the hooks.
+func WithRouteOptions(opts ...Option) RouteOption {
+	return func(rt *route) {
+		rt.opts = opts
+	}
+}
+
+// Terminal makes a signal end SignalRouter.Listen once the run it triggers
+// has returned, as is appropriate for shutdown signals. The run uses a
+// context that is detached from the context passed to Listen.
+func Terminal() RouteOption {
+	return func(rt *route) {
+		rt.terminal = true
+	}
+}
+
+// route is the handling of a signal by a SignalRouter.
+type route struct {
+	r        *Registry
+	timeout  time.Duration
+	opts     []Option
+	terminal bool
+}
+
+// SignalRouter dispatches signals to registries, for example SIGTERM to the
+// shutdown hooks, SIGQUIT to hooks dumping the state of the process and
+// SIGUSR1 to hooks rotating logs. It owns the signal.Notify registration for
+// all routed signals. The zero value is ready to use.
+type SignalRouter struct {
+	// ErrorHandler is called with the errors of runs triggered by signals
+	// that are not terminal. If nil, they are written to standard error.
+	ErrorHandler func(os.Signal, error)
+
+	mu     sync.Mutex
+	routes map[os.Signal]route
+}
+
+// Handle routes sig to r, replacing any previous route of sig. Routes added
+// while Listen is running take effect on the next call to Listen.
+func (s *SignalRouter) Handle(sig os.Signal, r *Registry, opts ...RouteOption) {
+	rt := route{r: r}
+	for _, opt := range opts {
+		opt(&rt)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.routes == nil {
+		s.routes = make(map[os.Signal]route)
+	}
+	s.routes[sig] = rt
+}
+
+// Listen blocks until a terminal signal has been handled or ctx is done,
+// and runs the registry routed to every signal received meanwhile. Runs
+// triggered by signals that are not terminal happen concurrently, with ctx,
+// and a signal received while its previous run is still in progress joins
+// that run, as with WithCoalesce. Listen returns the error of the terminal
+// run, or the context's error if ctx is done first.
+func (s *SignalRouter) Listen(ctx context.Context) error {
+	s.mu.Lock()
+	routes := make(map[os.Signal]route, len(s.routes))
+	signals := make([]os.Signal, 0, len(s.routes))
+	for sig, rt := range s.routes {
+		routes[sig] = rt
+		signals = append(signals, sig)
+	}
+	s.mu.Unlock()
+
+	// Notify relays all signals if none are given.
+	if len(signals) == 0 {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	sigs := make(chan os.Signal, len(signals))
+	signal.Notify(sigs, signals...)
+	defer signal.Stop(sigs)
+
+	for {
+		select {
+		case sig := <-sigs:
+			rt, ok := routes[sig]
+			if !ok {
+				continue
+			}
+			if rt.terminal {
+				return rt.run(context.WithoutCancel(ctx))
+			}
+			go func() {
+				if err := rt.run(ctx, WithCoalesce()); err != nil {
+					s.handleError(sig, err)
+				}
+			}()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// run runs the registry of the route with ctx limited to its timeout.
+func (rt route) run(ctx context.Context, opts ...Option) error {
+	if rt.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = withTimeout(ctx, rt.timeout, "route timeout")
+		defer cancel()
+	}
+	return rt.r.Run(ctx, append(opts, rt.opts...)...)
+}
+
+// handleError reports the error of a run triggered by sig.
+func (s *SignalRouter) handleError(sig os.Signal, err error) {
+	if s.ErrorHandler != nil {
+		s.ErrorHandler(sig, err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "hook: %v: %v\n", sig, err)
+}
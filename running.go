@@ -0,0 +1,63 @@
+package hook
+
+import "context"
+
+// IsRunning reports whether a run of the Registry is in progress. It can be
+// used, for example, by health endpoints to report that the process is
+// shutting down while its hooks execute.
+func (r *Registry) IsRunning() bool {
+	r.runMu.Lock()
+	defer r.runMu.Unlock()
+	return r.running > 0
+}
+
+// Await blocks until no run of the Registry is in progress or ctx is done,
+// in which case it returns the context's error. It returns immediately if
+// the registry is not running.
+func (r *Registry) Await(ctx context.Context) error {
+	r.runMu.Lock()
+	if r.running == 0 {
+		r.runMu.Unlock()
+		return nil
+	}
+	idle := r.idle
+	r.runMu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ShutdownInitiated returns a channel that is closed as soon as the first
+// run of the Registry begins. It lets components that are not hooks
+// themselves, such as worker loops and pollers, observe that shutdown has
+// started.
+func (r *Registry) ShutdownInitiated() <-chan struct{} {
+	return r.initiated.done()
+}
+
+// begin records the start of a run.
+func (r *Registry) begin() {
+	r.initiated.set()
+
+	r.runMu.Lock()
+	defer r.runMu.Unlock()
+	if r.running == 0 {
+		r.idle = make(chan struct{})
+	}
+	r.running++
+}
+
+// end records the end of a run and wakes up callers of Await once no run is
+// in progress anymore.
+func (r *Registry) end() {
+	r.runMu.Lock()
+	defer r.runMu.Unlock()
+	r.running--
+	if r.running == 0 {
+		close(r.idle)
+	}
+}
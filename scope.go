@@ -0,0 +1,37 @@
+package hook
+
+import "context"
+
+// Scope returns a new Registry for cleanup scoped to a function, and a done
+// function that runs it. It is an error- and context-aware alternative to
+// stacking defers, for example in a long constructor that has to release
+// what it has acquired so far if a later step fails:
+//
+//	scope, done := hook.Scope(ctx)
+//	db, err := openDB(ctx)
+//	if err != nil {
+//		return nil, errors.Join(err, done(ctx))
+//	}
+//	scope.Add(hook.FromCloser(db))
+//
+// done runs the registered hooks one at a time in reverse order of
+// registration and returns their errors joined. The hooks are removed as
+// they are run, so calling done again only runs hooks registered since. The
+// scope inherits the options of the Registry carried by ctx, if any.
+//
+// Since done typically runs after a failure, possibly because its context
+// was canceled, the hooks are run with a context that keeps the values of
+// the context passed to done but not its cancellation, limited to
+// DefaultShutdownTimeout, like the rollback of a Lifecycle.
+func Scope(ctx context.Context) (*Registry, func(context.Context) error) {
+	var opts []Option
+	if parent := FromContext(ctx); parent != nil {
+		opts = parent.opts
+	}
+	r := New(opts...)
+	return r, func(ctx context.Context) error {
+		ctx, cancel := withTimeout(context.WithoutCancel(ctx), DefaultShutdownTimeout, "cleanup timeout")
+		defer cancel()
+		return r.Run(ctx, WithSequential(), WithOrder(LIFO), WithConsumeOnRun(true))
+	}
+}
@@ -0,0 +1,48 @@
+package hook
+
+import "errors"
+
+// ErrSealed is returned, or used as the panic value, when a sealed Registry
+// is modified.
+var ErrSealed = errors.New("registry is sealed")
+
+// Seal prevents any further modification of the Registry, guaranteeing that
+// the hooks run on shutdown are the ones registered during startup. After
+// Seal, the methods registering or removing hooks or adding middleware,
+// including Token.Remove, panic with ErrSealed. Use TryRegister, TryRemove
+// and TryClear to get an error instead. Runs may still remove the hooks they
+// consume, such as those registered with AddOnce. Sealing cannot be undone.
+func (r *Registry) Seal() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sealed.Store(true)
+}
+
+// IsSealed reports whether Seal has been called.
+func (r *Registry) IsSealed() bool {
+	return r.sealed.Load()
+}
+
+// TryRemove is like Remove, but returns ErrSealed if r is sealed.
+func (r *Registry) TryRemove(name string) (bool, error) {
+	if name == "" {
+		return false, nil
+	}
+	return r.tryRemoveFunc(func(e entry) bool {
+		return e.name == name
+	})
+}
+
+// TryClear is like Clear, but returns ErrSealed if r is sealed.
+func (r *Registry) TryClear() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.sealed.Load() {
+		return ErrSealed
+	}
+	cleared := r.load()
+	r.store(nil)
+	r.notify(HooksCleared, cleared, nil)
+	return nil
+}
@@ -0,0 +1,127 @@
+package hook
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// DefaultForceExitCode is the status code a Listener exits with when a
+// second signal is received, following the shell convention of 128 plus the
+// number of SIGINT.
+const DefaultForceExitCode = 130
+
+// Listener runs a Registry when the process receives a shutdown signal.
+//
+// The first signal starts a graceful run of the registry. A second signal
+// received while the hooks are still running terminates the process
+// immediately, as operators expect from pressing Ctrl-C twice.
+type Listener struct {
+	// Registry is the registry to run. If nil, the default registry is used.
+	Registry *Registry
+
+	// Signals are the signals to listen for. If empty, SIGINT and SIGTERM
+	// are used.
+	Signals []os.Signal
+
+	// Grace limits the time the hooks may take, starting when shutdown is
+	// triggered. If zero or negative, the hooks are not limited.
+	Grace time.Duration
+
+	// Options are passed to the Run method of the registry.
+	Options []Option
+
+	// ForceExitCode is the status code to exit with on a second signal. If
+	// zero, DefaultForceExitCode is used.
+	ForceExitCode int
+
+	// ForceExitMessage, if not empty, is written to standard error before
+	// exiting on a second signal.
+	ForceExitMessage string
+
+	// DisableForceExit makes a second signal be ignored instead, so that the
+	// hooks always run to completion or until Grace expires.
+	DisableForceExit bool
+}
+
+// ListenAndRun blocks until one of the given signals is received or ctx is
+// done, then runs the default registry. See Registry.ListenAndRun.
+func ListenAndRun(ctx context.Context, grace time.Duration, signals ...os.Signal) error {
+	return Default().ListenAndRun(ctx, grace, signals...)
+}
+
+// ListenAndRun blocks until one of the given signals is received or ctx is
+// done, then runs the registry and returns the result of Run. If no signals
+// are given, SIGINT and SIGTERM are used. It is a shorthand for the
+// ListenAndRun method of a Listener with default settings.
+//
+// The hooks are run with a context that is detached from ctx and, if grace is
+// positive, expires grace after the shutdown was triggered. A second signal
+// received while the hooks are running terminates the process with
+// DefaultForceExitCode.
+func (r *Registry) ListenAndRun(ctx context.Context, grace time.Duration, signals ...os.Signal) error {
+	l := &Listener{Registry: r, Signals: signals, Grace: grace}
+	return l.ListenAndRun(ctx)
+}
+
+// ListenAndRun blocks until one of the signals of l is received or ctx is
+// done, then runs the registry and returns the result of Run. The hooks are
+// run with a context that is detached from ctx and limited by l.Grace.
+func (l *Listener) ListenAndRun(ctx context.Context) error {
+	r := l.Registry
+	if r == nil {
+		r = Default()
+	}
+	signals := l.Signals
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, signals...)
+	defer signal.Stop(sigs)
+
+	select {
+	case <-sigs:
+	case <-ctx.Done():
+	}
+
+	runCtx := context.WithoutCancel(ctx)
+	if l.Grace > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = withTimeout(runCtx, l.Grace, "grace period")
+		defer cancel()
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go l.forceExit(sigs, done)
+
+	return r.Run(runCtx, l.Options...)
+}
+
+// forceExit exits the process if a signal is received before done is
+// closed.
+func (l *Listener) forceExit(sigs <-chan os.Signal, done <-chan struct{}) {
+	for {
+		select {
+		case <-sigs:
+			if l.DisableForceExit {
+				continue
+			}
+			if l.ForceExitMessage != "" {
+				fmt.Fprintln(os.Stderr, l.ForceExitMessage)
+			}
+			code := l.ForceExitCode
+			if code == 0 {
+				code = DefaultForceExitCode
+			}
+			os.Exit(code)
+		case <-done:
+			return
+		}
+	}
+}
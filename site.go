@@ -0,0 +1,47 @@
+package hook
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// modulePath is the import path of this module. Frames of its packages,
+// including adapters such as hookhttp, are skipped when determining the site
+// a hook was registered from.
+const modulePath = "github.com/gulitsky/hook"
+
+// WithCallSites makes a Registry record the file and line every hook is
+// registered from. The site is included in reports, in the errors of hooks
+// that panic and by DebugHandler, which helps finding out which package
+// registered an anonymous hook that fails or hangs. Recording it costs a
+// stack walk per registration. It only has an effect when passed to New.
+func WithCallSites() Option {
+	return func(c *config) {
+		c.callSites = true
+	}
+}
+
+// callSite returns the file and line of the first caller outside this
+// module, or an empty string if there is none.
+func callSite() string {
+	var pcs [16]uintptr
+	n := runtime.Callers(2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		if !inModule(f.Function) {
+			return f.File + ":" + strconv.Itoa(f.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// inModule reports whether the function with the given fully qualified name
+// belongs to a package of this module.
+func inModule(fn string) bool {
+	rest, ok := strings.CutPrefix(fn, modulePath)
+	return ok && (strings.HasPrefix(rest, ".") || strings.HasPrefix(rest, "/"))
+}
@@ -0,0 +1,91 @@
+package hook
+
+import (
+	"context"
+	"time"
+)
+
+// DeadlineSplit is a strategy for dividing the time remaining until the
+// deadline of a run among its hooks, so that a single slow hook cannot
+// starve the hooks that run after it.
+type DeadlineSplit int
+
+const (
+	// NoSplit leaves the time of hooks unlimited, apart from the deadline of
+	// the run itself. This is the default.
+	NoSplit DeadlineSplit = iota
+
+	// SplitEqual gives every hook an equal share of the remaining time.
+	// Hooks that run concurrently share a slot, so in the default
+	// concurrent mode every priority group receives the same share.
+	SplitEqual
+
+	// SplitPriority weights the shares by priority: among the priority
+	// groups left to run, the group with the highest priority gets the
+	// largest share and the group with the lowest priority the smallest.
+	SplitPriority
+
+	// SplitStages gives every stage of a Stages pipeline an equal share of
+	// the remaining time, unless the stage has a shorter timeout of its own.
+	// It has no effect on the hooks within a stage.
+	SplitStages
+)
+
+// WithDeadlineSplit divides the time remaining until the deadline of the
+// context passed to Run among the hooks according to strategy. The budget
+// is recalculated at the start of every priority group, so time left over
+// by one group benefits the next. The budget chosen for every hook is
+// recorded in the report. Runs without a deadline are not affected, and
+// neither are hooks marked with MustRun.
+func WithDeadlineSplit(strategy DeadlineSplit) Option {
+	return func(c *config) {
+		c.split = strategy
+	}
+}
+
+// budget returns the time each hook of the first priority group of hooks
+// may take, or 0 if it is not limited. hooks holds the current priority
+// group followed by all later ones.
+func (rn *runner) budget(ctx context.Context, hooks []entry) time.Duration {
+	if rn.cfg.split != SplitEqual && rn.cfg.split != SplitPriority {
+		return 0
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0
+	}
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		return 0
+	}
+
+	// Hooks of a group run in slots of up to maxConcurrency hooks, one
+	// slot after another.
+	var slots []int
+	for rest := hooks; len(rest) > 0; {
+		n := 1
+		for n < len(rest) && rest[n].priority == rest[0].priority {
+			n++
+		}
+		s := 1
+		if limit := rn.cfg.maxConcurrency; limit > 0 {
+			s = (n + limit - 1) / limit
+		}
+		slots = append(slots, s)
+		rest = rest[n:]
+	}
+
+	var total float64
+	for i, s := range slots {
+		total += rn.weight(i, len(slots)) * float64(s)
+	}
+	return time.Duration(float64(remaining) * rn.weight(0, len(slots)) / total)
+}
+
+// weight returns the weight of the i-th of n remaining priority groups.
+func (rn *runner) weight(i, n int) float64 {
+	if rn.cfg.split == SplitPriority {
+		return float64(n - i)
+	}
+	return 1
+}
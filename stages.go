@@ -0,0 +1,197 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FailurePolicy determines how Stages proceeds after a stage fails.
+type FailurePolicy int
+
+const (
+	// Continue runs the remaining stages after a stage fails. This is the
+	// default, since shutdown should release as many resources as possible.
+	Continue FailurePolicy = iota
+
+	// Abort skips the remaining stages after a stage fails.
+	Abort
+)
+
+// StageError is the error reported for a stage whose hooks failed. It can be
+// retrieved from the error returned by Stages.Run with errors.As.
+type StageError struct {
+	// Stage is the name of the stage.
+	Stage string
+
+	// Err is the error returned by running the stage.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *StageError) Error() string {
+	return fmt.Sprintf("stage %q: %v", e.Stage, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// StageOption configures a stage.
+type StageOption func(*stage)
+
+// WithStageTimeout limits the time the hooks of a stage may take. The
+// timeout applies in addition to the deadline of the context passed to
+// Stages.Run.
+func WithStageTimeout(d time.Duration) StageOption {
+	return func(s *stage) {
+		s.timeout = d
+	}
+}
+
+// WithFailurePolicy sets how the remaining stages are handled if the stage
+// fails. The default is Continue.
+func WithFailurePolicy(p FailurePolicy) StageOption {
+	return func(s *stage) {
+		s.policy = p
+	}
+}
+
+// stage is a named Registry with its own timeout and failure policy.
+type stage struct {
+	name     string
+	registry *Registry
+	timeout  time.Duration
+	policy   FailurePolicy
+}
+
+// Stages is a pipeline of named stages, such as "stop-ingress", "drain" and
+// "close-resources". Each stage has its own Registry; the stages are run one
+// after another in the order they were created, while the hooks within a
+// stage run concurrently. It is safe for concurrent use.
+type Stages struct {
+	mu     sync.Mutex
+	stages []*stage
+}
+
+// NewStages creates a new, empty pipeline.
+func NewStages() *Stages {
+	return &Stages{}
+}
+
+// Stage returns the Registry of the stage with the given name, appending a
+// new stage to the pipeline if there is none. Options are applied to the
+// stage in either case.
+func (s *Stages) Stage(name string, opts ...StageOption) *Registry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var st *stage
+	for _, existing := range s.stages {
+		if existing.name == name {
+			st = existing
+			break
+		}
+	}
+	if st == nil {
+		st = &stage{name: name, registry: New()}
+		s.stages = append(s.stages, st)
+	}
+
+	for _, opt := range opts {
+		opt(st)
+	}
+	return st.registry
+}
+
+// StageReport describes the outcome of a single stage.
+type StageReport struct {
+	// Stage is the name of the stage.
+	Stage string
+
+	// Skipped reports whether the stage was not run, because an earlier
+	// stage with the Abort policy failed or the context was done.
+	Skipped bool
+
+	// Report is the report of the stage's run. It is nil if the stage was
+	// skipped or has no hooks.
+	Report *Report
+}
+
+// Run runs the stages in order, passing opts to the Run method of each
+// stage's Registry. Errors of failed stages are wrapped in a *StageError and
+// returned joined.
+//
+// Within a stage, no further hooks are started once the stage's context is
+// done, as with WithSequential, although the hooks still run concurrently.
+// Once the context passed to Run is done, or a stage with the Abort policy
+// fails, the remaining stages are skipped.
+func (s *Stages) Run(ctx context.Context, opts ...Option) error {
+	_, err := s.RunReport(ctx, opts...)
+	return err
+}
+
+// RunReport is like Run, but also returns a report for every stage, in the
+// order the stages were created.
+func (s *Stages) RunReport(ctx context.Context, opts ...Option) ([]StageReport, error) {
+	s.mu.Lock()
+	stages := make([]stage, len(s.stages))
+	for i, st := range s.stages {
+		stages[i] = *st
+	}
+	s.mu.Unlock()
+
+	split := newConfig(opts).split == SplitStages
+	opts = append([]Option{stopWhenDone}, opts...)
+
+	var (
+		reports = make([]StageReport, len(stages))
+		errs    []error
+		abort   bool
+	)
+	for i, st := range stages {
+		reports[i].Stage = st.name
+		if !abort && ctx.Err() != nil {
+			abort = true
+			errs = append(errs, context.Cause(ctx))
+		}
+		if abort {
+			reports[i].Skipped = true
+			continue
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && split {
+			share := time.Until(deadline) / time.Duration(len(stages)-i)
+			if st.timeout <= 0 || share < st.timeout {
+				st.timeout = share
+			}
+		}
+
+		rep, err := st.run(ctx, opts)
+		reports[i].Report = rep
+		if err != nil {
+			errs = append(errs, &StageError{Stage: st.name, Err: err})
+			abort = st.policy == Abort
+		}
+	}
+	return reports, errors.Join(errs...)
+}
+
+// stopWhenDone is the Option that stops starting hooks once the context of
+// a run is done.
+func stopWhenDone(c *config) {
+	c.stopWhenDone = true
+}
+
+// run runs the registry of the stage within its timeout.
+func (st *stage) run(ctx context.Context, opts []Option) (*Report, error) {
+	if st.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = withTimeout(ctx, st.timeout, fmt.Sprintf("stage %q timeout", st.name))
+		defer cancel()
+	}
+	return st.registry.RunReport(ctx, opts...)
+}
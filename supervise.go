@@ -0,0 +1,104 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// GoOption configures a goroutine started with Registry.Go.
+type GoOption func(*worker)
+
+// WithRestart makes a goroutine started with Registry.Go be restarted when
+// its function returns an error or panics, waiting between attempts as
+// determined by backoff. A nil backoff restarts immediately. At most limit
+// restarts are made, or any number if limit is not positive.
+func WithRestart(limit int, backoff BackoffFunc) GoOption {
+	return func(w *worker) {
+		w.restart = true
+		w.limit = limit
+		w.backoff = backoff
+	}
+}
+
+// worker is a goroutine supervised by a Registry.
+type worker struct {
+	fn      HookFunc
+	restart bool
+	limit   int
+	backoff BackoffFunc
+
+	// onExit, if set, is called with the error of a worker that failed for
+	// good before being stopped. The error is then not returned by the hook
+	// stopping the worker, so that it is reported only once.
+	onExit func(error)
+
+	done chan struct{}
+	err  error
+}
+
+// Go starts fn on a new goroutine tied to the lifetime of r, and registers a
+// hook under the given name that stops it: the hook cancels the context
+// passed to fn and waits for fn to return. The hook returns the error of the
+// last call of fn, or a *PanicError if it panicked, ignoring an error caused
+// by the cancellation itself. If the context of the run is done first, the
+// hook returns the context's error and the goroutine is abandoned. Removing
+// the hook with the returned Token does not stop the goroutine. Like
+// AddNamed, Go panics if r is sealed or the name is taken under
+// WithStrictNames, in which case fn is not started.
+func (r *Registry) Go(name string, fn HookFunc, opts ...GoOption) Token {
+	w := &worker{fn: fn, done: make(chan struct{})}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	// The stop hook is registered before the worker is started, so that a
+	// registration that panics, for example on a sealed registry, does not
+	// leave a worker behind that nothing can stop.
+	ctx, cancel := context.WithCancel(withName(context.Background(), name))
+	t := r.AddNamed(name, func(hctx context.Context) error {
+		cancel()
+		select {
+		case <-w.done:
+			return w.err
+		case <-hctx.Done():
+			return hctx.Err()
+		}
+	})
+	go w.run(ctx)
+	return t
+}
+
+// run calls the function of w until it succeeds, fails without being
+// restarted, or ctx is canceled.
+func (w *worker) run(ctx context.Context) {
+	defer func() {
+		if w.onExit != nil && w.err != nil && ctx.Err() == nil {
+			w.onExit(w.err)
+			w.err = nil
+		}
+		close(w.done)
+	}()
+
+	for attempt := 1; ; attempt++ {
+		_, err := call(ctx, w.fn, nil)
+		if ctx.Err() != nil {
+			if !errors.Is(err, context.Canceled) {
+				w.err = err
+			}
+			return
+		}
+		if err == nil || !w.restart || w.limit > 0 && attempt > w.limit {
+			w.err = err
+			return
+		}
+
+		var d time.Duration
+		if w.backoff != nil {
+			d = w.backoff(attempt)
+		}
+		if !sleep(ctx, d) {
+			return
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package hook
+
+import "slices"
+
+// WithTags attaches tags to a hook, so that a subset of the hooks of a
+// Registry can be run with MatchTags.
+func WithTags(tags ...string) HookOption {
+	return func(e *entry) {
+		e.tags = append(e.tags, tags...)
+	}
+}
+
+// MatchTags restricts a run to the hooks that carry at least one of the given
+// tags. Other hooks are neither run nor included in the report, and
+// dependencies on them are ignored.
+func MatchTags(tags ...string) Option {
+	return func(c *config) {
+		c.filters = append(c.filters, func(e entry) bool {
+			return slices.ContainsFunc(e.tags, func(tag string) bool {
+				return slices.Contains(tags, tag)
+			})
+		})
+	}
+}
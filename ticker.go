@@ -0,0 +1,106 @@
+package hook
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Schedule determines when a Ticker runs its registry. It can be implemented
+// to run hooks on a cron-like schedule.
+type Schedule interface {
+	// Next returns the time of the next run after the given time.
+	Next(time.Time) time.Time
+}
+
+// Every returns a Schedule that runs at a fixed interval d. Like
+// time.NewTicker, it panics if d is not positive.
+func Every(d time.Duration) Schedule {
+	if d <= 0 {
+		panic("hook: non-positive interval for Every")
+	}
+	return every(d)
+}
+
+// every implements Every.
+type every time.Duration
+
+// Next implements Schedule.
+func (d every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(d))
+}
+
+// TickerOption configures a Ticker.
+type TickerOption func(*Ticker)
+
+// WithJitter delays every run of a Ticker by a random duration of up to d,
+// so that many processes with the same schedule do not run at once.
+func WithJitter(d time.Duration) TickerOption {
+	return func(t *Ticker) {
+		t.jitter = d
+	}
+}
+
+// WithTickOptions sets the options every run of a Ticker is made with. For
+// example, MatchTags restricts the runs to a subset of the hooks.
+func WithTickOptions(opts ...Option) TickerOption {
+	return func(t *Ticker) {
+		t.opts = opts
+	}
+}
+
+// WithTickErrorHandler sets a function that is called with the error of
+// every failed run of a Ticker. By default, errors are discarded.
+func WithTickErrorHandler(fn func(error)) TickerOption {
+	return func(t *Ticker) {
+		t.errorHandler = fn
+	}
+}
+
+// Ticker runs a Registry periodically, for example for maintenance tasks,
+// with the same semantics as a single Run, including panic recovery and
+// error aggregation. The next run is scheduled only once the previous run
+// has returned, so runs never overlap.
+type Ticker struct {
+	r            *Registry
+	schedule     Schedule
+	jitter       time.Duration
+	opts         []Option
+	errorHandler func(error)
+}
+
+// NewTicker creates a Ticker that runs r on the given schedule.
+func NewTicker(r *Registry, schedule Schedule, opts ...TickerOption) *Ticker {
+	t := &Ticker{r: r, schedule: schedule}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Run runs the registry on the schedule until ctx is done, and then returns
+// the context's error. The context is passed to the runs, so a run in
+// progress is canceled as well.
+func (t *Ticker) Run(ctx context.Context) error {
+	next := time.Now()
+	for {
+		// The jitter is not added to next, so that it does not accumulate.
+		next = t.schedule.Next(next)
+		at := next
+		if t.jitter > 0 {
+			at = at.Add(rand.N(t.jitter))
+		}
+		if !sleep(ctx, time.Until(at)) {
+			return ctx.Err()
+		}
+
+		if err := t.r.Run(ctx, t.opts...); err != nil && t.errorHandler != nil {
+			t.errorHandler(err)
+		}
+
+		// A run that took longer than the interval skips the missed ticks.
+		if now := time.Now(); next.Before(now) {
+			next = now
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package hook
+
+// Token identifies the hook functions registered by a single call to one of
+// the Add methods of a Registry. The zero Token identifies no hooks.
+type Token struct {
+	r           *Registry
+	first, last uint64
+}
+
+// Remove deregisters the hook functions identified by the token. It reports
+// whether any of them was still registered. It is safe to call Remove more
+// than once.
+func (t Token) Remove() bool {
+	if t.r == nil || t.first > t.last {
+		return false
+	}
+	return t.r.removeFunc(func(e entry) bool {
+		return e.id >= t.first && e.id <= t.last
+	})
+}
@@ -0,0 +1,21 @@
+package hook
+
+import (
+	"context"
+	"runtime/trace"
+)
+
+// traceHook calls fn with ctx, recording the call as a region of the
+// execution trace named after the hook e if tracing is enabled. Every run
+// is recorded as a task named "hook.Run", so that go tool trace shows the
+// hooks of a run on a timeline.
+func traceHook(ctx context.Context, e entry, fn func(context.Context)) {
+	if !trace.IsEnabled() {
+		fn(ctx)
+		return
+	}
+
+	trace.WithRegion(ctx, "hook "+hookID(e), func() {
+		fn(ctx)
+	})
+}
@@ -0,0 +1,89 @@
+package hook
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// Warning marks an error as non-fatal. A hook that fails with a *Warning, for
+// example because an error filter downgraded its error, is recorded in the
+// Warnings of the report instead of making the run fail, like a best-effort
+// hook.
+type Warning struct {
+	Err error
+}
+
+// AsWarning wraps err in a *Warning. It returns nil if err is nil.
+func AsWarning(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Warning{Err: err}
+}
+
+// Error implements the error interface.
+func (w *Warning) Error() string {
+	return w.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (w *Warning) Unwrap() error {
+	return w.Err
+}
+
+// isWarning reports whether err is a *Warning itself, as opposed to merely
+// wrapping one.
+func isWarning(err error) bool {
+	_, ok := err.(*Warning)
+	return ok
+}
+
+// WithErrorFilter sets a function that is applied to the error of every
+// failed hook before it is handled any further. It can suppress expected
+// errors by returning nil, for example context.Canceled during shutdown, map
+// them to other errors, or downgrade them to warnings with AsWarning. Panics
+// are not filtered. fn may be called concurrently.
+func WithErrorFilter(fn func(error) error) Option {
+	return func(c *config) {
+		c.errorFilter = fn
+	}
+}
+
+// warningsKey is the context key for the warnings of the running hook.
+type warningsKey struct{}
+
+// warnings collects the warnings reported by a hook with Warn.
+type warnings struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+// Warn records err as a warning of the hook that is being run with ctx,
+// without making the hook fail. Warnings are listed in the HookResult of the
+// hook and in the Warnings of the report, separately from the errors of the
+// run, so that consumers need not tell severities apart by matching error
+// strings. Warn reports whether ctx belongs to a hook; if not, or if err is
+// nil, the warning is dropped.
+func Warn(ctx context.Context, err error) bool {
+	w, _ := ctx.Value(warningsKey{}).(*warnings)
+	if w == nil || err == nil {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.errs = append(w.errs, err)
+	return true
+}
+
+// withWarnings returns a context collecting the warnings of a hook in w.
+func withWarnings(ctx context.Context, w *warnings) context.Context {
+	return context.WithValue(ctx, warningsKey{}, w)
+}
+
+// list returns the warnings collected so far.
+func (w *warnings) list() []error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return slices.Clone(w.errs)
+}
@@ -0,0 +1,70 @@
+package hook
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// WatchdogFunc is called for a hook that has been running longer than the
+// watchdog threshold. The stack contains the stack traces of all goroutines,
+// which includes the one running the hook.
+type WatchdogFunc func(name string, elapsed time.Duration, stack []byte)
+
+// WithWatchdog calls fn once for every hook that is still running threshold
+// after it was started, along with a dump of all goroutine stacks, to help
+// diagnose hooks that hang. If fn is nil, the event is logged at warning
+// level to the logger set with WithLogger instead.
+func WithWatchdog(threshold time.Duration, fn WatchdogFunc) Option {
+	return func(c *config) {
+		c.watchdogThreshold = threshold
+		c.watchdog = fn
+	}
+}
+
+// watch starts the watchdog for the hook with the given name, if enabled.
+// The returned function stops it.
+func (c *config) watch(ctx context.Context, name string) (stop func() bool) {
+	if c.watchdogThreshold <= 0 || c.watchdog == nil && c.logger == nil {
+		return func() bool { return false }
+	}
+
+	start := time.Now()
+	t := time.AfterFunc(c.watchdogThreshold, func() {
+		elapsed := time.Since(start)
+		stack := allStacks()
+		if c.watchdog != nil {
+			c.watchdog(name, elapsed, stack)
+			return
+		}
+		c.logger.LogAttrs(ctx, slog.LevelWarn, "hook still running",
+			slog.String("hook", name),
+			slog.Duration("elapsed", elapsed),
+			slog.String("stack", string(stack)))
+	})
+	return t.Stop
+}
+
+// allStacks returns the stack traces of all goroutines.
+func allStacks() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// WithSlowHookCallback calls fn for every hook that took longer than d,
+// once the hook has returned, regardless of whether it succeeded. Unlike
+// WithWatchdog, it reports latency rather than hangs, which makes it suitable
+// for alerting on shutdown latency regressions.
+func WithSlowHookCallback(d time.Duration, fn func(name string, elapsed time.Duration)) Option {
+	return func(c *config) {
+		c.slowThreshold = d
+		c.slowHook = fn
+	}
+}